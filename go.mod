@@ -9,6 +9,7 @@ require (
 	github.com/spf13/cobra v1.7.0
 	k8s.io/api v0.28.14
 	k8s.io/apimachinery v0.28.14
+	k8s.io/cli-runtime v0.28.14
 	k8s.io/client-go v0.28.14
 	k8s.io/kubectl v0.28.14
 	k8s.io/metrics v0.28.14
@@ -64,7 +65,6 @@ require (
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-	k8s.io/cli-runtime v0.28.14 // indirect
 	k8s.io/klog/v2 v2.100.1 // indirect
 	k8s.io/kube-openapi v0.0.0-20230717233707-2695361300d9 // indirect
 	k8s.io/utils v0.0.0-20230406110748-d93618cff8a2 // indirect
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/resource"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	KRCostExample = templates.Examples(`
+	# prices.yaml, hourly unit prices per core, GiB and GPU
+	#   label: node.kubernetes.io/instance-type
+	#   poolLabel: karpenter.sh/nodepool
+	#   currency: USD
+	#   default: {cpu: 0.04, memory: 0.005}
+	#   prices:
+	#     m5.xlarge: {cpu: 0.032, memory: 0.004}
+	#     p3.2xlarge: {cpu: 0.032, memory: 0.004, gpu: 2.4}
+	kubectl kr cost --prices prices.yaml
+	kubectl kr cost --prices prices.yaml --hours 730
+	kubectl kr cost --prices prices.yaml --by workload -n default --top 10
+	kubectl kr cost --prices prices.yaml --by label=team -o json
+	`)
+)
+
+func costCmd() *cobra.Command {
+	o := resource.CostOption{}
+	costCmd := &cobra.Command{
+		Use:                   "cost",
+		Short:                 "cost attributes node prices to pods by max(requests, usage) and rolls them up, with the idle cost per node pool",
+		DisableFlagsInUseLine: true,
+		Example:               KRCostExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Snapshot = fromSnapshot
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.RunCost()
+		},
+	}
+	costCmd.PersistentFlags().StringVarP(&o.KubeCtx, "context", "", "", "context to use for Kubernetes config")
+	costCmd.PersistentFlags().StringVarP(&o.KubeConfig, "kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
+	costCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, csv, json, yaml (default table)")
+	costCmd.PersistentFlags().StringVarP(&o.Prices, "prices", "p", "", "price table file with the CPU, memory and GPU unit prices per instance type or node label")
+	costCmd.PersistentFlags().StringVarP(&o.By, "by", "", "namespace", "roll the costs up by one of: namespace, workload, pod, pool, label=<key>")
+	costCmd.PersistentFlags().Float64VarP(&o.Hours, "hours", "", 1, "number of hours the costs cover, e.g. 730 for a month")
+	costCmd.PersistentFlags().StringVarP(&o.PoolLabel, "pool-label", "", "", "node label grouping the nodes into pools for the idle cost, overrides poolLabel of the price table")
+	costCmd.PersistentFlags().StringVarP(&o.Namespace, "namespace", "n", "", "only include the pods of this namespace")
+	costCmd.PersistentFlags().StringVarP(&o.Selector, "selector", "l", "", "Selector (label query) to filter nodes on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	costCmd.PersistentFlags().IntVarP(&o.Top, "top", "", 0, "only show the N most expensive rows")
+	costCmd.PersistentFlags().StringVarP(&o.Lang, "lang", "", "", "language of the table headers. Allowed values: en, zh (default from LC_ALL, LC_MESSAGES or LANG, falling back to en)")
+	return costCmd
+}
+
+func init() {
+	rootCmd.AddCommand(costCmd())
+}
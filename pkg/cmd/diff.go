@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/resource"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	KRDiffExample = templates.Examples(`
+	kubectl kr node -o json > before.json
+	kubectl kr node -o json > after.json
+	kubectl kr diff before.json after.json --group-by karpenter.sh/nodepool
+	kubectl kr diff pods-before.json pods-after.json --sortBy memory-requests --top 10
+	kubectl kr diff before-snapshot.json after-snapshot.json --kind pod
+	`)
+)
+
+func diffCmd() *cobra.Command {
+	o := resource.DiffOption{}
+	diffCmd := &cobra.Command{
+		Use:                   "diff BEFORE AFTER",
+		DisableFlagsInUseLine: true,
+		Short:                 "diff compares two kr node or kr pod JSON reports, or two snapshots, per node or per workload",
+		Example:               KRDiffExample,
+		Args:                  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Before, o.After = args[0], args[1]
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.RunDiff()
+		},
+	}
+	diffCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, csv, json, yaml (default table)")
+	diffCmd.PersistentFlags().StringVarP(&o.Kind, "kind", "", "", "compare nodes or pods, required to compare pods from snapshots. Allowed values: node, pod (default from the reports)")
+	diffCmd.PersistentFlags().StringVarP(&o.GroupBy, "group-by", "", "", "compare nodes aggregated by the value of a label (e.g. karpenter.sh/nodepool)")
+	diffCmd.PersistentFlags().StringVarP(&o.SortBy, "sortBy", "s", "cpu-requests", fmt.Sprintf("put the biggest movers of one of: %s first", strings.Join(kube.DeltaSortKeys(), ", ")))
+	diffCmd.PersistentFlags().IntVarP(&o.Top, "top", "", 0, "only show the N biggest movers")
+	diffCmd.PersistentFlags().StringVarP(&o.Lang, "lang", "", "", "language of the table headers. Allowed values: en, zh (default from LC_ALL, LC_MESSAGES or LANG, falling back to en)")
+	diffCmd.PersistentFlags().StringVarP(&o.Units, "units", "", "auto", "units of cpu and memory in table and csv output. Allowed values: auto, raw, si, binary, cores, millicores")
+	return diffCmd
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd())
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/resource"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	KRFitExample = templates.Examples(`
+	kubectl kr fit -f pod.yaml
+	kubectl kr fit -f deployment.yaml --replicas 30
+	kubectl kr fit --cpu 2 --memory 4Gi --replicas 10
+	`)
+)
+
+func fitCmd() *cobra.Command {
+	o := resource.FitOption{}
+	fitCmd := &cobra.Command{
+		Use:                   "fit [-f FILENAME | --cpu CPU --memory MEMORY]",
+		Short:                 "fit reports how many replicas of a pod the nodes can still hold",
+		DisableFlagsInUseLine: true,
+		Example:               KRFitExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Snapshot = fromSnapshot
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.RunFit()
+		},
+	}
+	fitCmd.PersistentFlags().StringVarP(&o.KubeCtx, "context", "", "", "context to use for Kubernetes config")
+	fitCmd.PersistentFlags().StringVarP(&o.KubeConfig, "kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
+	fitCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, csv, json, yaml (default table)")
+	fitCmd.PersistentFlags().StringVarP(&o.Selector, "selector", "l", "", "Selector (label query) to filter nodes on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	fitCmd.PersistentFlags().StringVarP(&o.Filename, "filename", "f", "", "manifest of a pod or of a workload with a pod template (Deployment, StatefulSet, ReplicaSet, Job, CronJob), the first one of multi-document files is used, - reads stdin")
+	fitCmd.PersistentFlags().StringVarP(&o.CPU, "cpu", "", "", "cpu request of one replica, overrides the manifest (e.g. 500m, 2)")
+	fitCmd.PersistentFlags().StringVarP(&o.Memory, "memory", "", "", "memory request of one replica, overrides the manifest (e.g. 512Mi, 4Gi)")
+	fitCmd.PersistentFlags().IntVarP(&o.Replicas, "replicas", "", 0, "number of replicas to place, defaults to the manifest replicas or 1")
+	fitCmd.PersistentFlags().StringVarP(&o.Lang, "lang", "", "", "language of the table headers. Allowed values: en, zh (default from LC_ALL, LC_MESSAGES or LANG, falling back to en)")
+	fitCmd.PersistentFlags().StringVarP(&o.Units, "units", "", "auto", "units of cpu and memory in table and csv output. Allowed values: auto, raw, si, binary, cores, millicores")
+	return fitCmd
+}
+
+func init() {
+	rootCmd.AddCommand(fitCmd())
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/resource"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	KRNamespaceExample = templates.Examples(`
+	kubectl kr ns
+	kubectl kr ns --qos
+	kubectl kr ns -n kube-system -o json
+	kubectl kr ns --where 'pods > 100' --top 10
+	`)
+)
+
+func namespaceCmd() *cobra.Command {
+	o := resource.NamespaceOption{}
+	namespaceCmd := &cobra.Command{
+		Use:                   "namespace",
+		DisableFlagsInUseLine: true,
+		Short:                 "namespace sums the resources of the active pods per namespace",
+		Aliases:               []string{"namespaces", "ns"},
+		Example:               KRNamespaceExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Snapshot = fromSnapshot
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.RunResourceNamespace()
+		},
+	}
+	namespaceCmd.PersistentFlags().StringVarP(&o.KubeCtx, "context", "", "", "context to use for Kubernetes config")
+	namespaceCmd.PersistentFlags().StringVarP(&o.KubeConfig, "kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
+	namespaceCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, csv, json, yaml, custom-columns=..., custom-columns-file=..., go-template=..., go-template-file=..., jsonpath=..., jsonpath-file=... (default table)")
+	namespaceCmd.PersistentFlags().StringVarP(&o.Namespace, "namespace", "n", "", "only show the given namespace")
+	namespaceCmd.PersistentFlags().StringVarP(&o.Lang, "lang", "", "", "language of the table headers. Allowed values: en, zh (default from LC_ALL, LC_MESSAGES or LANG, falling back to en)")
+	namespaceCmd.PersistentFlags().StringVarP(&o.Units, "units", "", "auto", "units of cpu and memory in table and csv output. Allowed values: auto, raw, si, binary, cores, millicores")
+	namespaceCmd.PersistentFlags().IntVarP(&o.Top, "top", "", 0, "only show the first N rows after filtering")
+	namespaceCmd.PersistentFlags().StringVarP(&o.Where, "where", "", "", "filter rows on their computed values, using the JSON field names (e.g. --where 'memoryRequests > 8589934592')")
+	namespaceCmd.PersistentFlags().BoolVarP(&o.QOS, "qos", "", false, "split the pods and their requests by QoS class (Guaranteed, Burstable, BestEffort)")
+	return namespaceCmd
+}
+
+func init() {
+	rootCmd.AddCommand(namespaceCmd())
+}
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
 	"github.com/ysicing/kubectl-resource/pkg/resource"
 	"k8s.io/kubectl/pkg/util/templates"
 )
@@ -9,6 +13,17 @@ import (
 var (
 	KRNodeExample = templates.Examples(`
 	kubectl kr node
+	kubectl kr node -o wide
+	kubectl kr node --sortBy memory-request-fraction --top 10
+	kubectl kr node --where 'memoryRequestsFraction > 85'
+	kubectl kr node --group-by node.kubernetes.io/instance-type --expand
+	kubectl kr node --group-by karpenter.sh/nodepool --qos
+	kubectl kr node -o custom-columns=NAME:.nodeName,CPU:.cpuRequestsFraction
+	kubectl kr node -o jsonpath='{range .items[*]}{.nodeName}{"\t"}{.memoryRequestsFraction}{"\n"}{end}'
+	kubectl kr node --contexts prod-eu,prod-us --summary
+	kubectl kr node --all-contexts -o json
+	kubectl kr node --schedulable-only --exclude-tainted NoSchedule --summary
+	kubectl kr node --address-type ExternalIP
 	`)
 )
 
@@ -20,16 +35,34 @@ func nodeCmd() *cobra.Command {
 		Short:                 "node provides an overview of the node",
 		Aliases:               []string{"nodes", "no"},
 		Example:               KRNodeExample,
-		Run: func(cmd *cobra.Command, args []string) {
-			o.Validate()
-			o.RunResourceNode()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Snapshot = fromSnapshot
+			defaultSortByCluster(cmd, &o.SortBy, o.Contexts, o.AllContexts)
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.RunResourceNode()
 		},
 	}
 	nodeCmd.PersistentFlags().StringVarP(&o.KubeCtx, "context", "", "", "context to use for Kubernetes config")
 	nodeCmd.PersistentFlags().StringVarP(&o.KubeConfig, "kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
-	nodeCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, json, yaml (default table)")
+	nodeCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, wide, csv, json, yaml, custom-columns=..., custom-columns-file=..., go-template=..., go-template-file=..., jsonpath=..., jsonpath-file=... (default table)")
 	nodeCmd.PersistentFlags().StringVarP(&o.Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
-	nodeCmd.PersistentFlags().StringVarP(&o.SortBy, "sortBy", "s", "cpu", "sort by cpu or memory")
+	nodeCmd.PersistentFlags().StringVarP(&o.SortBy, "sortBy", "s", "cpu-usage", fmt.Sprintf("sort by one of: %s (cluster by default with --contexts or --all-contexts)", strings.Join(kube.NodeSortKeys(), ", ")))
+	nodeCmd.PersistentFlags().BoolVarP(&o.Reverse, "reverse", "", false, "reverse the sort order")
+	nodeCmd.PersistentFlags().StringVarP(&o.Lang, "lang", "", "", "language of the table headers. Allowed values: en, zh (default from LC_ALL, LC_MESSAGES or LANG, falling back to en)")
+	nodeCmd.PersistentFlags().StringVarP(&o.Units, "units", "", "auto", "units of cpu and memory in table and csv output. Allowed values: auto, raw, si, binary, cores, millicores")
+	nodeCmd.PersistentFlags().IntVarP(&o.Top, "top", "", 0, "only show the first N rows after filtering and sorting")
+	nodeCmd.PersistentFlags().StringVarP(&o.Where, "where", "", "", "filter rows on their computed values, using the JSON field names (e.g. --where 'memoryRequestsFraction > 80 && pods > 50')")
+	nodeCmd.PersistentFlags().BoolVarP(&o.Summary, "summary", "", false, "print the totals, weighted fractions and number of rows in warning or critical state")
+	nodeCmd.PersistentFlags().StringVarP(&o.GroupBy, "group-by", "", "", "aggregate nodes by the value of a label (e.g. karpenter.sh/nodepool, topology.kubernetes.io/zone)")
+	nodeCmd.PersistentFlags().BoolVarP(&o.QOS, "qos", "", false, "split the pods and their requests by QoS class (Guaranteed, Burstable, BestEffort)")
+	nodeCmd.PersistentFlags().BoolVarP(&o.Expand, "expand", "", false, "list the member nodes of every group, used with --group-by")
+	nodeCmd.PersistentFlags().StringVarP(&o.AddressType, "address-type", "", "InternalIP", fmt.Sprintf("node addresses shown in the IP column, both families on dual-stack nodes. Allowed values: %s", strings.Join(kube.AddressTypes(), ", ")))
+	nodeCmd.PersistentFlags().BoolVarP(&o.SchedulableOnly, "schedulable-only", "", false, "only include nodes that are Ready and not cordoned")
+	nodeCmd.PersistentFlags().StringSliceVarP(&o.ExcludeTainted, "exclude-tainted", "", nil, "leave out nodes with a taint of one of these effects: NoSchedule, PreferNoSchedule, NoExecute (e.g. --exclude-tainted=NoSchedule,NoExecute)")
+	nodeCmd.PersistentFlags().StringSliceVarP(&o.Contexts, "contexts", "", nil, "report on several kubeconfig contexts concurrently, each row prefixed with its cluster (e.g. --contexts ctx1,ctx2)")
+	nodeCmd.PersistentFlags().BoolVarP(&o.AllContexts, "all-contexts", "", false, "report on every context of the kubeconfig concurrently, each row prefixed with its cluster")
 	return nodeCmd
 }
 
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
 	"github.com/ysicing/kubectl-resource/pkg/resource"
 	"k8s.io/kubectl/pkg/util/templates"
 )
@@ -10,8 +14,16 @@ var (
 	KRPodExample = templates.Examples(`
 	kubectl kr pod
 	kubectl kr pod -l app=my-nginx
+	kubectl kr pod -n default -o wide
+	kubectl kr pod --where 'memoryUsagesFraction >= 90 && namespace != "kube-system"' --top 20
+	kubectl kr pod --health --sortBy memory-usage-fraction
+	kubectl kr pod --health --where 'oomKilled > 0'
 	kubectl kr pod -l app=my-nginx -o json
 	kubectl kr pod -l app=my-nginx -n default -o yaml
+	kubectl kr pod -o go-template='{{range .items}}{{.namespace}}/{{.name}} {{.memoryUsages}}{{"\n"}}{{end}}'
+	kubectl kr pod --contexts prod-eu,prod-us --summary
+	kubectl kr pod --group-by-label team --summary
+	kubectl kr pod --phase Pending
 	`)
 )
 
@@ -23,18 +35,33 @@ func podCmd() *cobra.Command {
 		DisableFlagsInUseLine: true,
 		Example:               KRPodExample,
 		Aliases:               []string{"pods", "po"},
-		Run: func(cmd *cobra.Command, args []string) {
-			o.Validate()
-			o.RunResourcePod()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Snapshot = fromSnapshot
+			defaultSortByCluster(cmd, &o.SortBy, o.Contexts, o.AllContexts)
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.RunResourcePod()
 		},
 	}
 	podCmd.PersistentFlags().StringVarP(&o.KubeCtx, "context", "", "", "context to use for Kubernetes config")
 	podCmd.PersistentFlags().StringVarP(&o.KubeConfig, "kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
-	podCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, json, yaml (default table)")
+	podCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, wide, csv, json, yaml, custom-columns=..., custom-columns-file=..., go-template=..., go-template-file=..., jsonpath=..., jsonpath-file=... (default table)")
 	podCmd.PersistentFlags().StringVarP(&o.LabelSelector, "label", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
 	podCmd.PersistentFlags().StringVarP(&o.FieldSelector, "field", "f", "", "Selector (field query) to filter on, supports '=', '==', and '!='.(e.g. -f key1=value1,key2=value2)")
-	podCmd.PersistentFlags().StringVarP(&o.SortBy, "sortBy", "s", "cpu", "sort by cpu or memory")
+	podCmd.PersistentFlags().StringVarP(&o.SortBy, "sortBy", "s", "cpu-usage", fmt.Sprintf("sort by one of: %s (cluster by default with --contexts or --all-contexts)", strings.Join(kube.PodSortKeys(), ", ")))
+	podCmd.PersistentFlags().BoolVarP(&o.Reverse, "reverse", "", false, "reverse the sort order")
 	podCmd.PersistentFlags().StringVarP(&o.Namespace, "namespace", "n", "", "only include rosource from this namespace")
+	podCmd.PersistentFlags().StringVarP(&o.Lang, "lang", "", "", "language of the table headers. Allowed values: en, zh (default from LC_ALL, LC_MESSAGES or LANG, falling back to en)")
+	podCmd.PersistentFlags().StringVarP(&o.Units, "units", "", "auto", "units of cpu and memory in table and csv output. Allowed values: auto, raw, si, binary, cores, millicores")
+	podCmd.PersistentFlags().IntVarP(&o.Top, "top", "", 0, "only show the first N rows after filtering and sorting")
+	podCmd.PersistentFlags().StringVarP(&o.Where, "where", "", "", "filter rows on their computed values, using the JSON field names (e.g. --where 'memoryUsagesFraction > 90 && restarts > 0')")
+	podCmd.PersistentFlags().StringVarP(&o.Phase, "phase", "", "", "only show the pods of this phase. Allowed values: Pending, Running, Succeeded, Failed, Unknown (default every phase but Succeeded and Failed)")
+	podCmd.PersistentFlags().StringVarP(&o.GroupByLabel, "group-by-label", "", "", "aggregate pods by the value of a pod label, falling back to the label of their namespace (e.g. team, app.kubernetes.io/part-of)")
+	podCmd.PersistentFlags().BoolVarP(&o.Health, "health", "", false, "show restarts, OOM kills, last terminations and recent Warning events next to the cpu and memory usage against the limits")
+	podCmd.PersistentFlags().StringSliceVarP(&o.Contexts, "contexts", "", nil, "report on several kubeconfig contexts concurrently, each row prefixed with its cluster (e.g. --contexts ctx1,ctx2)")
+	podCmd.PersistentFlags().BoolVarP(&o.AllContexts, "all-contexts", "", false, "report on every context of the kubeconfig concurrently, each row prefixed with its cluster")
+	podCmd.PersistentFlags().BoolVarP(&o.Summary, "summary", "", false, "print the totals, weighted fractions and number of rows in warning or critical state")
 	return podCmd
 }
 
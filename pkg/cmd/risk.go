@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/resource"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	KRRiskExample = templates.Examples(`
+	kubectl kr risk
+	kubectl kr risk --top 5 --pods 10
+	`)
+)
+
+func riskCmd() *cobra.Command {
+	o := resource.RiskOption{}
+	riskCmd := &cobra.Command{
+		Use:                   "risk",
+		Short:                 "risk ranks nodes by memory overcommit and lists the pods evicted first under memory pressure",
+		DisableFlagsInUseLine: true,
+		Example:               KRRiskExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Snapshot = fromSnapshot
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.RunRisk()
+		},
+	}
+	riskCmd.PersistentFlags().StringVarP(&o.KubeCtx, "context", "", "", "context to use for Kubernetes config")
+	riskCmd.PersistentFlags().StringVarP(&o.KubeConfig, "kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
+	riskCmd.PersistentFlags().StringVarP(&o.Output, "output", "o", "", "prints the output in the specified format. Allowed values: table, csv, json, yaml (default table)")
+	riskCmd.PersistentFlags().StringVarP(&o.Selector, "selector", "l", "", "Selector (label query) to filter nodes on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	riskCmd.PersistentFlags().IntVarP(&o.Top, "top", "", 0, "only show the N riskiest nodes")
+	riskCmd.PersistentFlags().IntVarP(&o.Pods, "pods", "", 5, "number of eviction candidates listed per node, 0 lists all")
+	riskCmd.PersistentFlags().StringVarP(&o.Lang, "lang", "", "", "language of the table headers. Allowed values: en, zh (default from LC_ALL, LC_MESSAGES or LANG, falling back to en)")
+	riskCmd.PersistentFlags().StringVarP(&o.Units, "units", "", "auto", "units of cpu and memory in table and csv output. Allowed values: auto, raw, si, binary, cores, millicores")
+	return riskCmd
+}
+
+func init() {
+	rootCmd.AddCommand(riskCmd())
+}
@@ -10,6 +10,25 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "kube-resource",
 	Short: "kube-resource provides an overview of the resource",
+	// errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+// fromSnapshot is the global --from-snapshot flag, the reports read the
+// snapshot file instead of the cluster when it is set
+var fromSnapshot string
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&fromSnapshot, "from-snapshot", "", "", "run against a file written by kr snapshot save instead of the cluster")
+}
+
+// defaultSortByCluster sorts the rows of several contexts by cluster, keeping
+// each cluster together, unless --sortBy is given explicitly
+func defaultSortByCluster(cmd *cobra.Command, sortBy *string, contexts []string, all bool) {
+	if (len(contexts) > 0 || all) && !cmd.Flags().Changed("sortBy") {
+		*sortBy = "cluster"
+	}
 }
 
 func Execute() {
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/resource"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	KRServeExample = templates.Examples(`
+	kubectl kr serve
+	kubectl kr serve --listen :9090 --metrics-interval 1m
+	kubectl kr serve --ui
+	`)
+)
+
+func serveCmd() *cobra.Command {
+	o := resource.ServeOption{}
+	serveCmd := &cobra.Command{
+		Use:                   "serve",
+		DisableFlagsInUseLine: true,
+		Short:                 "serve exposes the node and pod resources as Prometheus gauges on /metrics, and optionally a read-only dashboard",
+		Example:               KRServeExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Snapshot = fromSnapshot
+			return o.RunServe()
+		},
+	}
+	serveCmd.PersistentFlags().StringVarP(&o.KubeCtx, "context", "", "", "context to use for Kubernetes config")
+	serveCmd.PersistentFlags().StringVarP(&o.KubeConfig, "kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
+	serveCmd.PersistentFlags().StringVarP(&o.Listen, "listen", "", ":9090", "address to listen on")
+	serveCmd.PersistentFlags().DurationVarP(&o.Interval, "metrics-interval", "", 30*time.Second, "interval between two polls of the metrics API, nodes and pods are watched")
+	serveCmd.PersistentFlags().BoolVarP(&o.UI, "ui", "", false, "serve the dashboard on / and the JSON API on /api/v1/nodes, /api/v1/pods?namespace= and /api/v1/namespaces")
+	return serveCmd
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd())
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/ysicing/kubectl-resource/pkg/resource"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	KRSnapshotSaveExample = templates.Examples(`
+	kubectl kr snapshot save -f cluster.json
+	kubectl kr node --from-snapshot cluster.json
+	kubectl kr pod --from-snapshot cluster.json --health
+	`)
+)
+
+func snapshotCmd() *cobra.Command {
+	snapshotCmd := &cobra.Command{
+		Use:                   "snapshot",
+		DisableFlagsInUseLine: true,
+		Short:                 "snapshot captures the cluster into a file the reports can run against with --from-snapshot",
+	}
+	snapshotCmd.AddCommand(snapshotSaveCmd())
+	return snapshotCmd
+}
+
+func snapshotSaveCmd() *cobra.Command {
+	o := resource.SnapshotOption{}
+	saveCmd := &cobra.Command{
+		Use:                   "save -f FILENAME",
+		DisableFlagsInUseLine: true,
+		Short:                 "save writes the nodes, active pods, Warning events and node and pod metrics to a file",
+		Example:               KRSnapshotSaveExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Snapshot = fromSnapshot
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.RunSave()
+		},
+	}
+	saveCmd.PersistentFlags().StringVarP(&o.KubeCtx, "context", "", "", "context to use for Kubernetes config")
+	saveCmd.PersistentFlags().StringVarP(&o.KubeConfig, "kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
+	saveCmd.PersistentFlags().StringVarP(&o.Filename, "filename", "f", "", "file to write the snapshot to, - writes to stdout")
+	return saveCmd
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd())
+}
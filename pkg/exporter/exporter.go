@@ -0,0 +1,114 @@
+// Package exporter renders the computed node and pod resources in the
+// Prometheus text exposition format.
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+)
+
+// gauge describes a metric family and how to read its value from a row. CPU
+// is exposed in cores, memory in bytes and fractions as ratios from 0 to 1.
+// Unknown values are NaN and their series left out.
+type gauge[T any] struct {
+	name  string
+	help  string
+	value func(r *T) float64
+}
+
+func cores(millicores int64) float64 { return float64(millicores) / 1000 }
+
+func ratio(fraction float64) float64 { return fraction / 100 }
+
+// nodeUsage returns NaN for the nodes without metrics
+func nodeUsage(r *kube.NodeResources, v float64) float64 {
+	if r.NoMetrics {
+		return math.NaN()
+	}
+	return v
+}
+
+// podUsage returns NaN for the pods without metrics
+func podUsage(r *kube.PodsResources, v float64) float64 {
+	if r.NoMetrics {
+		return math.NaN()
+	}
+	return v
+}
+
+var nodeGauges = []gauge[kube.NodeResources]{
+	{"kr_node_cpu_usage_cores", "CPU used on the node.", func(r *kube.NodeResources) float64 { return nodeUsage(r, cores(r.CPUUsages)) }},
+	{"kr_node_cpu_requests_cores", "CPU requested by the active pods of the node.", func(r *kube.NodeResources) float64 { return cores(r.CPURequests) }},
+	{"kr_node_cpu_limits_cores", "CPU limits of the active pods of the node.", func(r *kube.NodeResources) float64 { return cores(r.CPULimits) }},
+	{"kr_node_cpu_allocatable_cores", "Allocatable CPU of the node.", func(r *kube.NodeResources) float64 { return cores(r.CPUCapacity) }},
+	{"kr_node_cpu_requests_ratio", "CPU requests over the allocatable CPU.", func(r *kube.NodeResources) float64 { return ratio(r.CPURequestsFraction) }},
+	{"kr_node_cpu_limits_ratio", "CPU limits over the allocatable CPU, above 1 when overcommitted.", func(r *kube.NodeResources) float64 { return ratio(r.CPULimitsFraction) }},
+	{"kr_node_memory_usage_bytes", "Memory used on the node.", func(r *kube.NodeResources) float64 { return nodeUsage(r, float64(r.MemoryUsages)) }},
+	{"kr_node_memory_requests_bytes", "Memory requested by the active pods of the node.", func(r *kube.NodeResources) float64 { return float64(r.MemoryRequests) }},
+	{"kr_node_memory_limits_bytes", "Memory limits of the active pods of the node.", func(r *kube.NodeResources) float64 { return float64(r.MemoryLimits) }},
+	{"kr_node_memory_allocatable_bytes", "Allocatable memory of the node.", func(r *kube.NodeResources) float64 { return float64(r.MemoryCapacity) }},
+	{"kr_node_memory_requests_ratio", "Memory requests over the allocatable memory.", func(r *kube.NodeResources) float64 { return ratio(r.MemoryRequestsFraction) }},
+	{"kr_node_memory_limits_ratio", "Memory limits over the allocatable memory, above 1 when overcommitted.", func(r *kube.NodeResources) float64 { return ratio(r.MemoryLimitsFraction) }},
+	{"kr_node_pods", "Active pods on the node.", func(r *kube.NodeResources) float64 { return float64(r.AllocatedPods) }},
+	{"kr_node_pods_allocatable", "Pods the node can hold.", func(r *kube.NodeResources) float64 { return float64(r.PodCapacity) }},
+	{"kr_node_pods_ratio", "Active pods over the pods the node can hold.", func(r *kube.NodeResources) float64 { return ratio(r.PodFraction) }},
+}
+
+var podGauges = []gauge[kube.PodsResources]{
+	{"kr_pod_cpu_usage_cores", "CPU used by the pod.", func(r *kube.PodsResources) float64 { return podUsage(r, cores(r.CPUUsages)) }},
+	{"kr_pod_cpu_requests_cores", "CPU requested by the pod.", func(r *kube.PodsResources) float64 { return cores(r.CPURequests) }},
+	{"kr_pod_cpu_limits_cores", "CPU limits of the pod.", func(r *kube.PodsResources) float64 { return cores(r.CPULimits) }},
+	{"kr_pod_cpu_usage_ratio", "CPU usage over the CPU limits of the pod, 0 without limits.", func(r *kube.PodsResources) float64 { return podUsage(r, ratio(r.CPUUsagesFraction)) }},
+	{"kr_pod_memory_usage_bytes", "Memory used by the pod.", func(r *kube.PodsResources) float64 { return podUsage(r, float64(r.MemoryUsages)) }},
+	{"kr_pod_memory_requests_bytes", "Memory requested by the pod.", func(r *kube.PodsResources) float64 { return float64(r.MemoryRequests) }},
+	{"kr_pod_memory_limits_bytes", "Memory limits of the pod.", func(r *kube.PodsResources) float64 { return float64(r.MemoryLimits) }},
+	{"kr_pod_memory_usage_ratio", "Memory usage over the memory limits of the pod, 0 without limits.", func(r *kube.PodsResources) float64 { return podUsage(r, ratio(r.MemoryUsagesFraction)) }},
+}
+
+// labelReplacer escapes label values as the exposition format requires
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels renders name/value pairs as {name="value",...}
+func labels(pairs ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, pairs[i], labelReplacer.Replace(pairs[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func writeFamily[T any](w *bufio.Writer, g gauge[T], rows []T, rowLabels func(r *T) string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	for i := range rows {
+		value := g.value(&rows[i])
+		if math.IsNaN(value) {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s\n", g.name, rowLabels(&rows[i]), strconv.FormatFloat(value, 'g', -1, 64))
+	}
+}
+
+// Write renders the node and pod gauges
+func Write(out io.Writer, nodes []kube.NodeResources, pods []kube.PodsResources) error {
+	w := bufio.NewWriter(out)
+	for _, g := range nodeGauges {
+		writeFamily(w, g, nodes, func(r *kube.NodeResources) string { return labels("node", r.NodeName) })
+	}
+	for _, g := range podGauges {
+		writeFamily(w, g, pods, func(r *kube.PodsResources) string {
+			return labels("namespace", r.Namespace, "pod", r.Name, "node", r.NodeName)
+		})
+	}
+	return w.Flush()
+}
@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+)
+
+func TestWrite(t *testing.T) {
+	nodes := []kube.NodeResources{
+		{NodeName: "n1", CPUUsages: 1500, CPURequests: 500, CPUCapacity: 4000, CPURequestsFraction: 12.5,
+			MemoryUsages: 1 << 30, MemoryLimits: 12 << 30, MemoryLimitsFraction: 150, AllocatedPods: 2, PodCapacity: 110},
+		{NodeName: "n2", CPURequests: 250, NoMetrics: true},
+	}
+	pods := []kube.PodsResources{
+		{Namespace: "default", Name: `we"b\1`, NodeName: "n1", CPUUsages: 250, CPULimits: 1000, CPUUsagesFraction: 25,
+			MemoryUsages: 512 << 20},
+		{Namespace: "default", Name: "fresh", NodeName: "n2", CPURequests: 100, NoMetrics: true},
+	}
+	var out bytes.Buffer
+	if err := Write(&out, nodes, pods); err != nil {
+		t.Fatal(err)
+	}
+	lines := map[string]bool{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		lines[line] = true
+	}
+
+	for _, want := range []string{
+		"# HELP kr_node_cpu_usage_cores CPU used on the node.",
+		"# TYPE kr_node_cpu_usage_cores gauge",
+		// millicores are exposed as cores, fractions as ratios
+		`kr_node_cpu_usage_cores{node="n1"} 1.5`,
+		`kr_node_cpu_requests_ratio{node="n1"} 0.125`,
+		// large values use the shortest representation, as client_golang does
+		`kr_node_memory_usage_bytes{node="n1"} 1.073741824e+09`,
+		`kr_node_memory_limits_ratio{node="n1"} 1.5`,
+		`kr_node_pods{node="n1"} 2`,
+		`kr_node_cpu_requests_cores{node="n2"} 0.25`,
+		// quotes and backslashes of label values are escaped
+		`kr_pod_cpu_usage_cores{namespace="default",pod="we\"b\\1",node="n1"} 0.25`,
+		`kr_pod_cpu_usage_ratio{namespace="default",pod="we\"b\\1",node="n1"} 0.25`,
+		`kr_pod_memory_usage_bytes{namespace="default",pod="we\"b\\1",node="n1"} 5.36870912e+08`,
+		`kr_pod_cpu_requests_cores{namespace="default",pod="fresh",node="n2"} 0.1`,
+	} {
+		if !lines[want] {
+			t.Errorf("Write() is missing %q, got:\n%s", want, out.String())
+		}
+	}
+
+	// the usage of rows without metrics is unknown, their series are left out
+	for _, prefix := range []string{
+		`kr_node_cpu_usage_cores{node="n2"}`,
+		`kr_node_memory_usage_bytes{node="n2"}`,
+		`kr_pod_cpu_usage_cores{namespace="default",pod="fresh"`,
+		`kr_pod_memory_usage_ratio{namespace="default",pod="fresh"`,
+	} {
+		if strings.Contains(out.String(), prefix) {
+			t.Errorf("Write() has the unknown series %s", prefix)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	tests := []struct {
+		pairs []string
+		want  string
+	}{
+		{nil, "{}"},
+		{[]string{"node", "n1"}, `{node="n1"}`},
+		{[]string{"a", "x", "b", "y"}, `{a="x",b="y"}`},
+		{[]string{"pod", "line\nbreak"}, `{pod="line\nbreak"}`},
+		{[]string{"pod", `C:\tmp "q"`}, `{pod="C:\\tmp \"q\""}`},
+	}
+	for _, tt := range tests {
+		if got := labels(tt.pairs...); got != tt.want {
+			t.Errorf("labels(%q) = %s, want %s", tt.pairs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// aliases lets expressions use the short names shown in the tables, they only
+// apply to rows without a field of that name
+var aliases = map[string]string{
+	"pods": "allocatedPods",
+}
+
+// Expr is a parsed --where expression such as
+// `memoryRequestsFraction > 80 && pods > 50`. Identifiers are the JSON field
+// names of the rows, compared against number, string or boolean literals.
+type Expr struct {
+	raw  string
+	root node
+	// fields lists the identifiers of the expression
+	fields []string
+	// zero holds the zero value of every field of the bound row type, for the
+	// fields left out of the JSON by omitempty
+	zero map[string]interface{}
+}
+
+// Parse compiles a filter expression
+func Parse(expr string) (*Expr, error) {
+	p := &parser{lex: newLexer(expr)}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid filter %q", expr)
+	}
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("invalid filter %q: unexpected %q", expr, p.tok.text)
+	}
+	return &Expr{raw: expr, root: root, fields: p.fields}, nil
+}
+
+// Bind checks the identifiers of the expression against the JSON fields of the
+// rows it will match, row being any value of their type. Fields left out by
+// omitempty then compare as their zero value.
+func (e *Expr) Bind(row interface{}) error {
+	zero := map[string]interface{}{}
+	jsonFields(reflect.TypeOf(row), zero)
+	for _, field := range e.fields {
+		if _, ok := lookup(zero, field); !ok {
+			return fmt.Errorf("invalid filter %q: unknown field %q", e.raw, field)
+		}
+	}
+	e.zero = zero
+	return nil
+}
+
+// jsonFields adds the JSON names of the fields of a struct type with their
+// zero value, numbers being float64 as encoding/json decodes them
+func jsonFields(t reflect.Type, zero map[string]interface{}) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && len(name) == 0 {
+			jsonFields(f.Type, zero)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		switch f.Type.Kind() {
+		case reflect.Bool:
+			zero[name] = false
+		case reflect.String:
+			zero[name] = ""
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			zero[name] = float64(0)
+		default:
+			zero[name] = nil
+		}
+	}
+}
+
+// lookup returns the value of a field, falling back to its alias
+func lookup(fields map[string]interface{}, field string) (interface{}, bool) {
+	if v, ok := fields[field]; ok {
+		return v, true
+	}
+	if alias, ok := aliases[field]; ok {
+		v, ok := fields[alias]
+		return v, ok
+	}
+	return nil, false
+}
+
+// String returns the expression as given
+func (e *Expr) String() string {
+	return e.raw
+}
+
+// Match evaluates the expression against a typed row
+func (e *Expr) Match(row interface{}) (bool, error) {
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return false, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false, err
+	}
+	for field, v := range e.zero {
+		if _, ok := fields[field]; !ok {
+			fields[field] = v
+		}
+	}
+	ok, err := e.root.eval(fields)
+	if err != nil {
+		return false, errors.Wrapf(err, "evaluating filter %q", e.raw)
+	}
+	return ok, nil
+}
+
+// node is a boolean expression
+type node interface {
+	eval(fields map[string]interface{}) (bool, error)
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(fields map[string]interface{}) (bool, error) {
+	ok, err := n.left.eval(fields)
+	if err != nil || !ok {
+		return false, err
+	}
+	return n.right.eval(fields)
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(fields map[string]interface{}) (bool, error) {
+	ok, err := n.left.eval(fields)
+	if err != nil || ok {
+		return ok, err
+	}
+	return n.right.eval(fields)
+}
+
+type notNode struct{ expr node }
+
+func (n notNode) eval(fields map[string]interface{}) (bool, error) {
+	ok, err := n.expr.eval(fields)
+	return !ok, err
+}
+
+// operand is either a field reference or a literal
+type operand struct {
+	field   string
+	literal interface{}
+}
+
+func (o operand) value(fields map[string]interface{}) (interface{}, error) {
+	if len(o.field) == 0 {
+		return o.literal, nil
+	}
+	v, ok := lookup(fields, o.field)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", o.field)
+	}
+	return v, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right operand
+}
+
+func (n compareNode) eval(fields map[string]interface{}) (bool, error) {
+	l, err := n.left.value(fields)
+	if err != nil {
+		return false, err
+	}
+	r, err := n.right.value(fields)
+	if err != nil {
+		return false, err
+	}
+	switch lv := l.(type) {
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return false, fmt.Errorf("can not compare number with %v", r)
+		}
+		return compare(n.op, lv < rv, lv == rv)
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return false, fmt.Errorf("can not compare string with %v", r)
+		}
+		return compare(n.op, lv < rv, lv == rv)
+	case bool:
+		rv, ok := r.(bool)
+		if !ok {
+			return false, fmt.Errorf("can not compare boolean with %v", r)
+		}
+		if n.op != "==" && n.op != "!=" {
+			return false, fmt.Errorf("operator %s is not supported for booleans", n.op)
+		}
+		return compare(n.op, false, lv == rv)
+	default:
+		return false, fmt.Errorf("can not compare %v", l)
+	}
+}
+
+func compare(op string, less, equal bool) (bool, error) {
+	switch op {
+	case "<":
+		return less, nil
+	case "<=":
+		return less || equal, nil
+	case ">":
+		return !less && !equal, nil
+	case ">=":
+		return !less, nil
+	case "==":
+		return equal, nil
+	case "!=":
+		return !equal, nil
+	}
+	return false, fmt.Errorf("unknown operator %s", op)
+}
+
+type parser struct {
+	lex    *lexer
+	tok    token
+	fields []string
+}
+
+func (p *parser) next() (err error) {
+	p.tok, err = p.lex.next()
+	return
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOr {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokAnd {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch p.tok.kind {
+	case tokNot:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expr}, nil
+	case tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return expr, p.next()
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokOp {
+		return nil, fmt.Errorf("expected a comparison operator, got %q", p.tok.text)
+	}
+	op := p.tok.text
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.tok
+	var o operand
+	switch tok.kind {
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			o.literal = tok.text == "true"
+		default:
+			o.field = tok.text
+			p.fields = append(p.fields, tok.text)
+		}
+	case tokNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return o, fmt.Errorf("invalid number %q", tok.text)
+		}
+		o.literal = v
+	case tokString:
+		o.literal = tok.text
+	default:
+		return o, fmt.Errorf("expected a field or a value, got %q", tok.text)
+	}
+	return o, p.next()
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func newLexer(input string) *lexer {
+	return &lexer{input: input}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && (l.input[l.pos] == ' ' || l.input[l.pos] == '\t') {
+		l.pos++
+	}
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF}, nil
+	}
+	rest := l.input[l.pos:]
+	for _, t := range []struct {
+		text string
+		kind tokenKind
+	}{
+		{"&&", tokAnd}, {"||", tokOr},
+		{">=", tokOp}, {"<=", tokOp}, {"==", tokOp}, {"!=", tokOp},
+		{">", tokOp}, {"<", tokOp}, {"!", tokNot}, {"(", tokLParen}, {")", tokRParen},
+	} {
+		if strings.HasPrefix(rest, t.text) {
+			l.pos += len(t.text)
+			return token{kind: t.kind, text: t.text}, nil
+		}
+	}
+	c := rest[0]
+	switch {
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(rest[1:], c)
+		if end < 0 {
+			return token{}, fmt.Errorf("unterminated string")
+		}
+		l.pos += end + 2
+		return token{kind: tokString, text: rest[1 : end+1]}, nil
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		n := 1
+		for n < len(rest) && (rest[n] == '.' || (rest[n] >= '0' && rest[n] <= '9')) {
+			n++
+		}
+		l.pos += n
+		return token{kind: tokNumber, text: rest[:n]}, nil
+	case isIdentChar(c):
+		n := 1
+		for n < len(rest) && isIdentChar(rest[n]) {
+			n++
+		}
+		l.pos += n
+		return token{kind: tokIdent, text: rest[:n]}, nil
+	}
+	return token{}, fmt.Errorf("unexpected character %q", c)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
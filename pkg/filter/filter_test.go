@@ -0,0 +1,183 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+)
+
+type nodeRow struct {
+	NodeName               string  `json:"nodeName"`
+	MemoryRequestsFraction float64 `json:"memoryRequestsFraction"`
+	AllocatedPods          int     `json:"allocatedPods"`
+	Ready                  bool    `json:"ready"`
+	Cluster                string  `json:"cluster,omitempty"`
+}
+
+type namespaceRow struct {
+	Namespace string `json:"namespace"`
+	Pods      int    `json:"pods"`
+}
+
+type summary struct {
+	Warning int `json:"warning"`
+}
+
+type groupRow struct {
+	Value string `json:"value"`
+	summary
+}
+
+func TestMatch(t *testing.T) {
+	row := nodeRow{NodeName: "n1", MemoryRequestsFraction: 85, AllocatedPods: 40, Ready: true}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"memoryRequestsFraction > 80", true},
+		{"memoryRequestsFraction >= 85", true},
+		{"memoryRequestsFraction < 85", false},
+		{"memoryRequestsFraction <= 85.0", true},
+		{"memoryRequestsFraction == 85 && allocatedPods != 40", false},
+		{"memoryRequestsFraction > 90 || allocatedPods == 40", true},
+		{"allocatedPods > -1", true},
+		{`nodeName == "n1"`, true},
+		{`nodeName == 'n2'`, false},
+		{`nodeName < "n2"`, true},
+		{`"n1" == nodeName`, true},
+		{"ready == true", true},
+		{"ready != true", false},
+		{"!ready == false", true},
+		// && binds tighter than ||
+		{"allocatedPods == 0 && ready == true || nodeName == \"n1\"", true},
+		{"allocatedPods == 0 && (ready == true || nodeName == \"n1\")", false},
+		{"nodeName == \"n2\" || allocatedPods == 40 && ready == false", false},
+		{"!(allocatedPods == 0) && ready == true", true},
+		// pods is the short name of allocatedPods
+		{"pods > 30 && pods < 50", true},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		got, err := e.Match(row)
+		if err != nil {
+			t.Errorf("Match(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	row := nodeRow{NodeName: "n1", AllocatedPods: 40, Ready: true}
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"nodeName > 1", "can not compare string"},
+		{`allocatedPods == "40"`, "can not compare number"},
+		{"ready > false", "not supported for booleans"},
+		{"memoryRequestFraction > 80", `unknown field "memoryRequestFraction"`},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if _, err := e.Match(row); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Match(%q) error = %v, want %q", tt.expr, err, tt.err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"", "expected a field or a value"},
+		{"pods", "expected a comparison operator"},
+		{"pods >", "expected a field or a value"},
+		{"pods > 1 &&", "expected a field or a value"},
+		{"(pods > 1", "missing closing parenthesis"},
+		{"pods > 1)", `unexpected ")"`},
+		{`nodeName == "n1`, "unterminated string"},
+		{"pods > 1 # comment", "unexpected character"},
+		{"pods > 1 pods < 2", `unexpected "pods"`},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.expr); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.expr, err, tt.err)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		expr string
+		row  interface{}
+		err  string
+	}{
+		{"memoryRequestsFraction > 80 && pods > 50", nodeRow{}, ""},
+		{`cluster == "prod"`, nodeRow{}, ""},
+		{"memoryRequestFraction > 80", nodeRow{}, `unknown field "memoryRequestFraction"`},
+		{"pods > 50", namespaceRow{}, ""},
+		{"memoryRequestsFraction > 80", namespaceRow{}, `unknown field "memoryRequestsFraction"`},
+		// fields of embedded structs are promoted
+		{"warning > 0", groupRow{}, ""},
+		{"warning > 0", &groupRow{}, ""},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		err = e.Bind(tt.row)
+		if len(tt.err) == 0 && err != nil {
+			t.Errorf("Bind(%q, %T) failed: %v", tt.expr, tt.row, err)
+		}
+		if len(tt.err) > 0 && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Bind(%q, %T) error = %v, want %q", tt.expr, tt.row, err, tt.err)
+		}
+	}
+}
+
+func TestBoundMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		row  interface{}
+		want bool
+	}{
+		// cluster is left out by omitempty and compares as ""
+		{`cluster == ""`, nodeRow{NodeName: "n1"}, true},
+		{`cluster == "prod"`, nodeRow{NodeName: "n1", Cluster: "prod"}, true},
+		// a real pods field wins over the alias
+		{"pods > 50", namespaceRow{Pods: 60}, true},
+		{"warning == 2", groupRow{summary: summary{Warning: 2}}, true},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if err := e.Bind(tt.row); err != nil {
+			t.Errorf("Bind(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		got, err := e.Match(tt.row)
+		if err != nil {
+			t.Errorf("Match(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package i18n
+
+// catalog maps message ids, mostly table column ids, to their translations
+var catalog = map[Lang]map[string]string{
+	EN: {
+		"name":               "Name",
+		"namespace":          "Namespace",
+		"ip":                 "IP",
+		"internalIP":         "Internal IP",
+		"externalIP":         "External IP",
+		"hostname":           "Hostname",
+		"cpuUsages":          "CPU Usage",
+		"cpuRequests":        "CPU Requests",
+		"cpuLimits":          "CPU Limits",
+		"cpuCapacity":        "CPU Capacity",
+		"memoryUsages":       "Memory Usage",
+		"memoryRequests":     "Memory Requests",
+		"memoryLimits":       "Memory Limits",
+		"memoryCapacity":     "Memory Capacity",
+		"pods":               "Pods",
+		"podCapacity":        "Pod Capacity",
+		"age":                "Age",
+		"roles":              "Roles",
+		"kubeletVersion":     "Version",
+		"osImage":            "OS Image",
+		"zone":               "Zone",
+		"instanceType":       "Instance Type",
+		"taints":             "Taints",
+		"conditions":         "Conditions",
+		"schedulable":        "Schedulable",
+		"nodeName":           "Node",
+		"qosClass":           "QoS",
+		"restarts":           "Restarts",
+		"phase":              "Phase",
+		"priorityClass":      "Priority Class",
+		"owner":              "Owner",
+		"total":              "Total",
+		"nodes":              "Nodes",
+		"namespaces":         "Namespaces",
+		"cpuFree":            "CPU Free",
+		"memoryFree":         "Memory Free",
+		"podsFree":           "Pods Free",
+		"fits":               "Fits",
+		"reason":             "Reason",
+		"message":            "Message",
+		"aboveRequests":      "Above Requests",
+		"score":              "Score",
+		"guaranteed":         "Guaranteed",
+		"burstable":          "Burstable",
+		"bestEffort":         "BestEffort",
+		"guaranteedFraction": "Guaranteed%",
+		"oomKilled":          "OOMKilled",
+		"lastTerminated":     "Last Terminated",
+		"warnings":           "Warnings",
+		"lastWarning":        "Last Warning",
+		"workload":           "Workload",
+		"status":             "Status",
+		"cluster":            "Cluster",
+		"pod":                "Pod",
+		"pool":               "Pool",
+		"cpuCost":            "CPU Cost",
+		"memoryCost":         "Memory Cost",
+		"gpuCost":            "GPU Cost",
+		"totalCost":          "Total Cost",
+		"nodeCost":           "Node Cost",
+		"allocatedCost":      "Allocated Cost",
+		"idleCost":           "Idle Cost",
+		"unpriced":           "%d nodes have no price and cost nothing: %s",
+		"noMetrics":          "%d nodes have no metrics, their usage is unknown: %s",
+		"pendingPods":        "%d pods are Pending:",
+		"replicasFit":        "%d replicas of %s CPU / %s memory fit, %d requested",
+		"warning":            "Warning",
+		"critical":           "Critical",
+	},
+	ZH: {
+		"name":               "Name",
+		"namespace":          "Namespace",
+		"ip":                 "IP",
+		"internalIP":         "内网IP",
+		"externalIP":         "公网IP",
+		"hostname":           "主机名",
+		"cpuUsages":          "CPU使用",
+		"cpuRequests":        "CPU分配",
+		"cpuLimits":          "CPU限制",
+		"cpuCapacity":        "CPU容量",
+		"memoryUsages":       "内存使用",
+		"memoryRequests":     "内存分配",
+		"memoryLimits":       "内存限制",
+		"memoryCapacity":     "内存容量",
+		"pods":               "pod数",
+		"podCapacity":        "pod容量",
+		"age":                "存活时间",
+		"roles":              "角色",
+		"kubeletVersion":     "Kubelet版本",
+		"osImage":            "系统镜像",
+		"zone":               "可用区",
+		"instanceType":       "实例类型",
+		"taints":             "污点数",
+		"conditions":         "节点压力",
+		"schedulable":        "可调度",
+		"nodeName":           "节点",
+		"qosClass":           "QoS",
+		"restarts":           "重启次数",
+		"phase":              "状态",
+		"priorityClass":      "优先级",
+		"owner":              "所属",
+		"total":              "合计",
+		"nodes":              "节点数",
+		"namespaces":         "命名空间数",
+		"cpuFree":            "CPU空闲",
+		"memoryFree":         "内存空闲",
+		"podsFree":           "pod空闲",
+		"fits":               "可容纳",
+		"reason":             "原因",
+		"message":            "信息",
+		"aboveRequests":      "超出分配",
+		"score":              "风险值",
+		"guaranteed":         "Guaranteed",
+		"burstable":          "Burstable",
+		"bestEffort":         "BestEffort",
+		"guaranteedFraction": "Guaranteed占比",
+		"oomKilled":          "OOM次数",
+		"lastTerminated":     "上次终止",
+		"warnings":           "告警事件",
+		"lastWarning":        "最近告警",
+		"workload":           "工作负载",
+		"status":             "状态",
+		"cluster":            "集群",
+		"pod":                "Pod",
+		"pool":               "节点池",
+		"cpuCost":            "CPU成本",
+		"memoryCost":         "内存成本",
+		"gpuCost":            "GPU成本",
+		"totalCost":          "总成本",
+		"nodeCost":           "节点成本",
+		"allocatedCost":      "已分配成本",
+		"idleCost":           "闲置成本",
+		"unpriced":           "%d 个节点没有价格，按零计算: %s",
+		"noMetrics":          "%d 个节点没有监控指标，使用量未知: %s",
+		"pendingPods":        "%d 个 Pod 处于 Pending 状态:",
+		"replicasFit":        "可容纳 %d 个副本 (CPU %s / 内存 %s), 需要 %d 个",
+		"warning":            "警告",
+		"critical":           "严重",
+	},
+}
@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lang is a language the message catalog has translations for
+type Lang string
+
+const (
+	EN Lang = "en"
+	ZH Lang = "zh"
+)
+
+// Langs returns a list of the string representation of the supported languages
+func Langs() []string {
+	return []string{EN.String(), ZH.String()}
+}
+
+// String returns the string representation of the Lang
+func (l Lang) String() string {
+	return string(l)
+}
+
+// Validate checks a --lang flag value, empty picks the language from the
+// environment
+func Validate(flag string) error {
+	if len(flag) == 0 {
+		return nil
+	}
+	for _, l := range Langs() {
+		if l == flag {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown language %q, allowed values: %s", flag, strings.Join(Langs(), ", "))
+}
+
+// Detect picks the language from the --lang flag value, falling back to the
+// LC_ALL, LC_MESSAGES and LANG environment variables. English is used unless
+// the chosen locale is Chinese.
+func Detect(flag string) Lang {
+	locale := flag
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if len(locale) > 0 {
+			break
+		}
+		locale = os.Getenv(env)
+	}
+	if strings.HasPrefix(strings.ToLower(locale), ZH.String()) {
+		return ZH
+	}
+	return EN
+}
+
+// T returns the translation of the message id, falling back to English and
+// finally to the id itself
+func (l Lang) T(id string) string {
+	if msg, ok := catalog[l][id]; ok {
+		return msg
+	}
+	if msg, ok := catalog[EN][id]; ok {
+		return msg
+	}
+	return id
+}
+
+// Headers translates a list of column ids into a table header row
+func (l Lang) Headers(ids ...string) []interface{} {
+	header := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		header = append(header, l.T(id))
+	}
+	return header
+}
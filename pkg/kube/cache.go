@@ -0,0 +1,394 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	metricsV1beta1api "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// podNodeNameIndex indexes the cached pods by spec.nodeName
+const podNodeNameIndex = "spec.nodeName"
+
+// CacheOptions tunes the informer-backed client
+type CacheOptions struct {
+	// MetricsInterval is the interval between two polls of the metrics API,
+	// 30s when zero
+	MetricsInterval time.Duration
+	// Resync is the resync period of the informers, none when zero
+	Resync time.Duration
+}
+
+// cacheLoader serves the nodes and the active pods from shared informers and
+// the metrics from a poller, so that the reports can be recomputed without
+// listing the cluster every time. Events are still read from the API.
+type cacheLoader struct {
+	api        *apiLoader
+	nodes      corelisters.NodeLister
+	pods       corelisters.PodLister
+	podIndexer cache.Indexer
+
+	mu          sync.RWMutex
+	nodeMetrics *metricsV1beta1api.NodeMetricsList
+	podMetrics  *metricsV1beta1api.PodMetricsList
+
+	// generation counts the changes seen by the informers and the poller.
+	// nodeGenerations holds the last change of every node or of its pods,
+	// metricsGeneration the last poll, and rows the node rows computed at a
+	// given generation.
+	rowsMu            sync.Mutex
+	generation        uint64
+	nodeGenerations   map[string]uint64
+	metricsGeneration uint64
+	rows              map[string]cachedNodeRow
+}
+
+type cachedNodeRow struct {
+	generation uint64
+	row        NodeResources
+}
+
+// NewCachedKubeClient returns a client backed by node and pod informers, the
+// metrics being polled periodically. The informers and the poller run until
+// ctx is done, the client is returned once the caches are synced. The API
+// load stays constant however often the reports are recomputed.
+func NewCachedKubeClient(ctx context.Context, cc *ClientConfig, opts CacheOptions) (*KubeClient, error) {
+	client, metricsClient, err := New(cc)
+	if err != nil {
+		return nil, err
+	}
+	return NewCachedKubeClientForClients(ctx, client, metricsClient, opts)
+}
+
+// NewCachedKubeClientForClients is NewCachedKubeClient for callers that
+// already hold their clientsets, e.g. daemons embedding this package
+func NewCachedKubeClientForClients(ctx context.Context, client kubernetes.Interface, metricsClient metrics.Interface, opts CacheOptions) (*KubeClient, error) {
+	if opts.MetricsInterval <= 0 {
+		opts.MetricsInterval = 30 * time.Second
+	}
+	l, err := newCacheLoader(ctx, &apiLoader{apiClient: client, metricsClient: metricsClient}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &KubeClient{loader: l}, nil
+}
+
+func newCacheLoader(ctx context.Context, api *apiLoader, opts CacheOptions) (*cacheLoader, error) {
+	client := api.apiClient
+	l := &cacheLoader{
+		api:             api,
+		nodeMetrics:     &metricsV1beta1api.NodeMetricsList{},
+		podMetrics:      &metricsV1beta1api.PodMetricsList{},
+		nodeGenerations: map[string]uint64{},
+		rows:            map[string]cachedNodeRow{},
+	}
+
+	nodeFactory := informers.NewSharedInformerFactory(client, opts.Resync)
+	// the pod informer only watches active pods, like GetActivePodByNodename
+	podFactory := informers.NewSharedInformerFactoryWithOptions(client, opts.Resync,
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.AndSelectors(
+				fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+				fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+			).String()
+		}))
+	nodeInformer := nodeFactory.Core().V1().Nodes().Informer()
+	if _, err := nodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { l.nodeChanged(obj) },
+		UpdateFunc: func(_, obj interface{}) { l.nodeChanged(obj) },
+		DeleteFunc: func(obj interface{}) { l.nodeChanged(obj) },
+	}); err != nil {
+		return nil, err
+	}
+	l.nodes = nodeFactory.Core().V1().Nodes().Lister()
+	podInformer := podFactory.Core().V1().Pods().Informer()
+	if err := podInformer.AddIndexers(cache.Indexers{podNodeNameIndex: func(obj interface{}) ([]string, error) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %T", obj)
+		}
+		return []string{pod.Spec.NodeName}, nil
+	}}); err != nil {
+		return nil, err
+	}
+	if _, err := podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { l.podChanged(obj) },
+		UpdateFunc: func(old, obj interface{}) {
+			// a pod bound since the last event also changes its new node
+			l.podChanged(old)
+			l.podChanged(obj)
+		},
+		DeleteFunc: func(obj interface{}) { l.podChanged(obj) },
+	}); err != nil {
+		return nil, err
+	}
+	l.podIndexer = podInformer.GetIndexer()
+	l.pods = podFactory.Core().V1().Pods().Lister()
+
+	nodeFactory.Start(ctx.Done())
+	podFactory.Start(ctx.Done())
+	for _, factory := range []informers.SharedInformerFactory{nodeFactory, podFactory} {
+		for informer, ok := range factory.WaitForCacheSync(ctx.Done()) {
+			if !ok {
+				return nil, fmt.Errorf("unable to sync the %v cache", informer)
+			}
+		}
+	}
+
+	l.pollMetrics(ctx)
+	go l.pollMetricsEvery(ctx, opts.MetricsInterval)
+	return l, nil
+}
+
+// pollMetricsEvery polls the metrics until ctx is done, the first poll being
+// one interval after the synchronous one of newCacheLoader
+func (l *cacheLoader) pollMetricsEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.pollMetrics(ctx)
+		}
+	}
+}
+
+// tombstoneObject unwraps the objects deleted while the watch was down
+func tombstoneObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
+
+// nodeChanged invalidates the row of a node, dropping it once deleted
+func (l *cacheLoader) nodeChanged(obj interface{}) {
+	node, ok := tombstoneObject(obj).(*corev1.Node)
+	if !ok {
+		return
+	}
+	l.rowsMu.Lock()
+	defer l.rowsMu.Unlock()
+	l.generation++
+	l.nodeGenerations[node.Name] = l.generation
+	if _, err := l.nodes.Get(node.Name); apierrors.IsNotFound(err) {
+		delete(l.nodeGenerations, node.Name)
+		delete(l.rows, node.Name)
+	}
+}
+
+// podChanged invalidates the row of the node the pod is bound to
+func (l *cacheLoader) podChanged(obj interface{}) {
+	pod, ok := tombstoneObject(obj).(*corev1.Pod)
+	if !ok || len(pod.Spec.NodeName) == 0 {
+		return
+	}
+	l.rowsMu.Lock()
+	defer l.rowsMu.Unlock()
+	l.generation++
+	l.nodeGenerations[pod.Spec.NodeName] = l.generation
+}
+
+// nodeGeneration returns the generation of the last change a node row
+// depends on
+func (l *cacheLoader) nodeGeneration(name string) uint64 {
+	l.rowsMu.Lock()
+	defer l.rowsMu.Unlock()
+	if g := l.nodeGenerations[name]; g > l.metricsGeneration {
+		return g
+	}
+	return l.metricsGeneration
+}
+
+// cachedNodeRow returns the row of a node computed at the given generation
+func (l *cacheLoader) cachedNodeRow(name string, generation uint64) (NodeResources, bool) {
+	l.rowsMu.Lock()
+	defer l.rowsMu.Unlock()
+	cached, ok := l.rows[name]
+	if !ok || cached.generation != generation {
+		return NodeResources{}, false
+	}
+	return cached.row, true
+}
+
+// storeNodeRow keeps the row of a node computed at the given generation,
+// unless the node is gone meanwhile
+func (l *cacheLoader) storeNodeRow(name string, generation uint64, row NodeResources) {
+	if _, err := l.nodes.Get(name); err != nil {
+		return
+	}
+	l.rowsMu.Lock()
+	defer l.rowsMu.Unlock()
+	l.rows[name] = cachedNodeRow{generation: generation, row: row}
+}
+
+// cachedNodeResources reuses the row of a node when neither the node, its
+// pods nor the metrics changed since it was computed, which makes recomputing
+// the reports of an informer-backed client incremental
+func (k *KubeClient) cachedNodeResources(node corev1.Node) (NodeResources, error) {
+	l, ok := k.loader.(*cacheLoader)
+	if !ok {
+		return k.nodeResources(node)
+	}
+	// read the generation first, a change racing with the computation then
+	// only makes the next call recompute
+	generation := l.nodeGeneration(node.Name)
+	if row, ok := l.cachedNodeRow(node.Name, generation); ok {
+		return row, nil
+	}
+	row, err := k.nodeResources(node)
+	if err == nil {
+		l.storeNodeRow(node.Name, generation, row)
+	}
+	return row, err
+}
+
+// pollMetrics refreshes the node and pod metrics, keeping the previous ones
+// when the metrics API fails
+func (l *cacheLoader) pollMetrics(ctx context.Context) {
+	nodeMetrics, err := l.api.listNodeMetrics(metav1.ListOptions{})
+	if err != nil {
+		log.Printf("Couldn't poll node metrics: %s\n", err)
+		return
+	}
+	podMetrics, err := l.api.listPodMetrics(metav1.NamespaceAll, metav1.ListOptions{})
+	if err != nil {
+		log.Printf("Couldn't poll pod metrics: %s\n", err)
+		return
+	}
+	l.mu.Lock()
+	l.nodeMetrics, l.podMetrics = nodeMetrics, podMetrics
+	l.mu.Unlock()
+	l.rowsMu.Lock()
+	l.generation++
+	l.metricsGeneration = l.generation
+	l.rowsMu.Unlock()
+}
+
+// metricsSnapshot returns a snapshot of the last polled metrics, used to apply
+// the selectors of metrics requests. The nodes are only needed to match the
+// label selectors of node metrics.
+func (l *cacheLoader) metricsSnapshot(withNodes bool) *snapshotLoader {
+	s := &Snapshot{}
+	if withNodes {
+		nodes, _ := l.nodes.List(labels.Everything())
+		s.Nodes = make([]corev1.Node, 0, len(nodes))
+		for _, node := range nodes {
+			s.Nodes = append(s.Nodes, *node)
+		}
+	}
+	l.mu.RLock()
+	s.NodeMetrics, s.PodMetrics = l.nodeMetrics.Items, l.podMetrics.Items
+	l.mu.RUnlock()
+	return &snapshotLoader{snapshot: s}
+}
+
+func (l *cacheLoader) getNode(name string) (*corev1.Node, error) {
+	node, err := l.nodes.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return node.DeepCopy(), nil
+}
+
+func (l *cacheLoader) listNodes(opts metav1.ListOptions) (*corev1.NodeList, error) {
+	labelSelector, fieldSelector, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := l.nodes.List(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.NodeList{}
+	for _, node := range nodes {
+		if fieldSelector.Matches(objectMetaFields(node.ObjectMeta)) {
+			list.Items = append(list.Items, *node.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+// getPod falls back to the API for the Succeeded and Failed pods the
+// informer does not watch
+func (l *cacheLoader) getPod(namespace, name string) (*corev1.Pod, error) {
+	pod, err := l.pods.Pods(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return l.api.getPod(namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pod.DeepCopy(), nil
+}
+
+// listPods serves the active pods, using the node index when the field
+// selector pins spec.nodeName. Selectors requiring the Succeeded or Failed
+// phase the informer does not watch fall back to the API.
+func (l *cacheLoader) listPods(namespace string, opts metav1.ListOptions) (*corev1.PodList, error) {
+	labelSelector, fieldSelector, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if phase, ok := fieldSelector.RequiresExactMatch("status.phase"); ok {
+		if phase == string(corev1.PodSucceeded) || phase == string(corev1.PodFailed) {
+			return l.api.listPods(namespace, opts)
+		}
+	}
+	var objs []interface{}
+	if nodeName, ok := fieldSelector.RequiresExactMatch("spec.nodeName"); ok {
+		objs, err = l.podIndexer.ByIndex(podNodeNameIndex, nodeName)
+	} else {
+		objs = l.podIndexer.List()
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.PodList{}
+	for _, obj := range objs {
+		pod := obj.(*corev1.Pod)
+		if inNamespace(namespace, pod.ObjectMeta) && labelSelector.Matches(labels.Set(pod.Labels)) && fieldSelector.Matches(podFields(pod)) {
+			list.Items = append(list.Items, *pod.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (l *cacheLoader) listEvents(namespace string, opts metav1.ListOptions) (*corev1.EventList, error) {
+	return l.api.listEvents(namespace, opts)
+}
+
+func (l *cacheLoader) listNamespaces(opts metav1.ListOptions) (*corev1.NamespaceList, error) {
+	return l.api.listNamespaces(opts)
+}
+
+func (l *cacheLoader) getNodeMetrics(name string) (*metricsV1beta1api.NodeMetrics, error) {
+	return l.metricsSnapshot(false).getNodeMetrics(name)
+}
+
+func (l *cacheLoader) listNodeMetrics(opts metav1.ListOptions) (*metricsV1beta1api.NodeMetricsList, error) {
+	return l.metricsSnapshot(len(opts.LabelSelector) > 0).listNodeMetrics(opts)
+}
+
+func (l *cacheLoader) listPodMetrics(namespace string, opts metav1.ListOptions) (*metricsV1beta1api.PodMetricsList, error) {
+	return l.metricsSnapshot(false).listPodMetrics(namespace, opts)
+}
+
+func (l *cacheLoader) now() time.Time {
+	return time.Now()
+}
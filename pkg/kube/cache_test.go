@@ -0,0 +1,243 @@
+package kube
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	metricsV1beta1api "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+var nodeMetricsResource = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "nodes"}
+
+func testNode(name, cpu, memory string, labels map[string]string) *corev1.Node {
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status:     corev1.NodeStatus{Capacity: capacity, Allocatable: capacity},
+	}
+}
+
+func testPod(name, nodeName, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{{Name: "app", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			}}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func testNodeMetrics(name, cpu, memory string) *metricsV1beta1api.NodeMetrics {
+	return &metricsV1beta1api.NodeMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Usage: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		},
+	}
+}
+
+func testPodMetrics(name, cpu, memory string) *metricsV1beta1api.PodMetrics {
+	return &metricsV1beta1api.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Containers: []metricsV1beta1api.ContainerMetrics{{Name: "app", Usage: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}}},
+	}
+}
+
+// eventually polls cond until the informers caught up
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newTestCachedClient(t *testing.T, ctx context.Context) (*KubeClient, *cacheLoader, *fake.Clientset, *metricsfake.Clientset) {
+	t.Helper()
+	client := fake.NewSimpleClientset(testNode("n1", "4", "8Gi", nil), testNode("n2", "4", "8Gi", nil), testPod("web", "n1", "500m", "128Mi"))
+	metricsClient := metricsfake.NewSimpleClientset()
+	// the tracker would guess the resource of NodeMetrics wrong, register
+	// the objects under the resource the typed client lists
+	for _, m := range []*metricsV1beta1api.NodeMetrics{testNodeMetrics("n1", "1", "1Gi"), testNodeMetrics("n2", "200m", "1Gi")} {
+		if err := metricsClient.Tracker().Create(nodeMetricsResource, m, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a long interval, the tests poll by hand
+	k, err := NewCachedKubeClientForClients(ctx, client, metricsClient, CacheOptions{MetricsInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := k.loader.(*cacheLoader)
+	// the handlers may still be delivering the initial adds of the two
+	// nodes and the pod after the caches synced, on top of the first poll
+	eventually(t, "the initial events", func() bool {
+		l.rowsMu.Lock()
+		defer l.rowsMu.Unlock()
+		return l.generation >= 4
+	})
+	return k, l, client, metricsClient
+}
+
+func nodeRowsByName(t *testing.T, k *KubeClient) map[string]NodeResources {
+	t.Helper()
+	rows, err := k.GetNodeResources(labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]NodeResources{}
+	for _, r := range rows {
+		byName[r.NodeName] = r
+	}
+	return byName
+}
+
+func TestCacheReusesRows(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k, l, _, _ := newTestCachedClient(t, ctx)
+
+	rows := nodeRowsByName(t, k)
+	if rows["n1"].CPURequests != 500 || rows["n1"].CPUUsages != 1000 {
+		t.Fatalf("n1 row = %+v, want 500m requested and 1 CPU used", rows["n1"])
+	}
+	generation := l.nodeGeneration("n1")
+	cached, ok := l.cachedNodeRow("n1", generation)
+	if !ok {
+		t.Fatalf("n1 row not cached at generation %d", generation)
+	}
+	// a cached row is served as is, prove it by tampering with it
+	cached.KubeletVersion = "cached"
+	l.storeNodeRow("n1", generation, cached)
+	if got := nodeRowsByName(t, k)["n1"].KubeletVersion; got != "cached" {
+		t.Errorf("n1 row recomputed without any change, kubelet version %q", got)
+	}
+}
+
+func TestCachePodChangeInvalidatesItsNode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k, l, client, _ := newTestCachedClient(t, ctx)
+	nodeRowsByName(t, k)
+	n1, n2 := l.nodeGeneration("n1"), l.nodeGeneration("n2")
+
+	if _, err := client.CoreV1().Pods("default").Create(ctx, testPod("api", "n1", "250m", "128Mi"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the pod event", func() bool { return l.nodeGeneration("n1") > n1 })
+	if got := l.nodeGeneration("n2"); got != n2 {
+		t.Errorf("n2 generation moved from %d to %d on a pod of n1", n2, got)
+	}
+	if got := nodeRowsByName(t, k)["n1"].CPURequests; got != 750 {
+		t.Errorf("n1 cpu requests = %d, want 750", got)
+	}
+}
+
+func TestCacheNodeDeleteDropsRow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k, l, client, _ := newTestCachedClient(t, ctx)
+	nodeRowsByName(t, k)
+
+	if err := client.CoreV1().Nodes().Delete(ctx, "n2", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the node delete event", func() bool {
+		l.rowsMu.Lock()
+		defer l.rowsMu.Unlock()
+		_, cached := l.rows["n2"]
+		_, tracked := l.nodeGenerations["n2"]
+		return !cached && !tracked
+	})
+	rows := nodeRowsByName(t, k)
+	if _, ok := rows["n2"]; ok || len(rows) != 1 {
+		t.Errorf("rows after deleting n2 = %v, want n1 only", rows)
+	}
+	// a row computed for a node deleted meanwhile is not kept
+	l.storeNodeRow("n2", l.nodeGeneration("n2"), NodeResources{NodeName: "n2"})
+	if _, ok := l.cachedNodeRow("n2", l.nodeGeneration("n2")); ok {
+		t.Errorf("row of the deleted n2 stored")
+	}
+}
+
+func TestCachePollMetricsBumpsRows(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k, l, _, metricsClient := newTestCachedClient(t, ctx)
+	nodeRowsByName(t, k)
+	before := l.nodeGeneration("n1")
+
+	if err := metricsClient.Tracker().Update(nodeMetricsResource, testNodeMetrics("n1", "3", "1Gi"), ""); err != nil {
+		t.Fatal(err)
+	}
+	// the rows only see the new metrics once polled
+	if got := nodeRowsByName(t, k)["n1"].CPUUsages; got != 1000 {
+		t.Errorf("n1 cpu usage before the poll = %d, want 1000", got)
+	}
+	l.pollMetrics(ctx)
+	for _, name := range []string{"n1", "n2"} {
+		if got := l.nodeGeneration(name); got <= before {
+			t.Errorf("%s generation %d not bumped past %d by the poll", name, got, before)
+		}
+	}
+	if got := nodeRowsByName(t, k)["n1"].CPUUsages; got != 3000 {
+		t.Errorf("n1 cpu usage after the poll = %d, want 3000", got)
+	}
+}
+
+func TestCacheListsTerminatedPodsFromAPI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, l, client, _ := newTestCachedClient(t, ctx)
+
+	job := testPod("job", "n1", "100m", "128Mi")
+	job.Status.Phase = corev1.PodSucceeded
+	if _, err := client.CoreV1().Pods("default").Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// the informer only watches the active pods
+	active, err := l.listPods("", metav1.ListOptions{FieldSelector: PodPhaseSelector("").String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := podListNames(active), []string{"default/web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listPods(active) = %v, want %v", got, want)
+	}
+	succeeded, err := l.listPods("", metav1.ListOptions{FieldSelector: PodPhaseSelector("Succeeded").String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, pod := range succeeded.Items {
+		found = found || pod.Name == "job"
+	}
+	if !found {
+		t.Errorf("listPods(Succeeded) = %v, want the job listed from the API", podListNames(succeeded))
+	}
+}
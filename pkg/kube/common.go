@@ -2,12 +2,16 @@ package kube
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/logrusorgru/aurora/v3"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
 )
 
 const (
@@ -15,6 +19,18 @@ const (
 	criticalThreshold = 90.00
 )
 
+// Unknown is rendered for values that could not be measured
+const Unknown = "<unknown>"
+
+// Age renders the time elapsed from t to now the way kubectl does, e.g. 412d
+// or 3h5m
+func Age(t metav1.Time, now time.Time) string {
+	if t.IsZero() {
+		return Unknown
+	}
+	return duration.HumanDuration(now.Sub(t.Time))
+}
+
 // NewGpuResource returns the list of NewGpuResource
 func NewGpuResource(name v1.ResourceName, rl *v1.ResourceList) *resource.Quantity {
 	if val, ok := (*rl)[name]; ok {
@@ -47,8 +63,19 @@ func (r *MemoryResource) calcPercentage(divisor *resource.Quantity) float64 {
 }
 
 func (r *MemoryResource) String() string {
-	// XXX: Support more units
-	return fmt.Sprintf("%vMi", r.Value()/(1024*1024))
+	return r.Format(UnitsRaw)
+}
+
+// Format renders the memory in the given units
+func (r *MemoryResource) Format(u Units) string {
+	switch u {
+	case UnitsRaw:
+		return fmt.Sprintf("%vMi", r.Value()/(1024*1024))
+	case UnitsSI:
+		return scaleUnits(r.Value(), 1000, []string{"", "k", "M", "G", "T", "P"})
+	default:
+		return scaleUnits(r.Value(), 1024, []string{"", "Ki", "Mi", "Gi", "Ti", "Pi"})
+	}
 }
 
 // ToQuantity
@@ -68,8 +95,64 @@ func NewCPUResource(value int64) *CPUResource {
 
 // String
 func (r *CPUResource) String() string {
-	// XXX: Support more units
-	return fmt.Sprintf("%vm", r.MilliValue())
+	return r.Format(UnitsRaw)
+}
+
+// Format renders the cpu in the given units
+func (r *CPUResource) Format(u Units) string {
+	switch u {
+	case UnitsRaw, UnitsMillicores:
+		return fmt.Sprintf("%vm", r.MilliValue())
+	case UnitsCores:
+		return formatScaled(float64(r.MilliValue())/1000, 2)
+	default:
+		if r.MilliValue() < 1000 {
+			return fmt.Sprintf("%vm", r.MilliValue())
+		}
+		return formatScaled(float64(r.MilliValue())/1000, 2)
+	}
+}
+
+// Units selects how cpu and memory values are rendered in tables. cores and
+// millicores only pin the cpu unit, si and binary only pin the memory unit,
+// the other dimension is scaled automatically.
+type Units string
+
+const (
+	UnitsAuto       Units = "auto"
+	UnitsRaw        Units = "raw"
+	UnitsSI         Units = "si"
+	UnitsBinary     Units = "binary"
+	UnitsCores      Units = "cores"
+	UnitsMillicores Units = "millicores"
+)
+
+// ParseUnits takes a raw string and returns the matching Units
+func ParseUnits(s string) (Units, error) {
+	switch u := Units(strings.ToLower(s)); u {
+	case "":
+		return UnitsAuto, nil
+	case UnitsAuto, UnitsRaw, UnitsSI, UnitsBinary, UnitsCores, UnitsMillicores:
+		return u, nil
+	default:
+		return "", fmt.Errorf("invalid units %q, allowed values: auto, raw, si, binary, cores, millicores", s)
+	}
+}
+
+// scaleUnits divides value by base until it fits the largest suffix
+func scaleUnits(value int64, base float64, suffixes []string) string {
+	v := float64(value)
+	i := 0
+	for ; i < len(suffixes)-1 && v >= base; i++ {
+		v /= base
+	}
+	return formatScaled(v, 1) + suffixes[i]
+}
+
+// formatScaled rounds to the given number of decimals, dropping trailing zeros
+func formatScaled(v float64, decimals int) string {
+	pow := math.Pow(10, float64(decimals))
+	return strconv.FormatFloat(math.Round(v*pow)/pow, 'f', -1, 64)
 }
 
 // float64ToString float64转string
@@ -132,3 +215,14 @@ func redColor(s string) string {
 func yellowColor(s string) string {
 	return fmt.Sprintf("%s", aurora.Yellow(s))
 }
+
+// FractionString renders a percentage for tabular output
+func FractionString(f float64) string {
+	return float64ToString(f)
+}
+
+// ExceedsFraction renders a percentage for tabular output, colored once it
+// crosses the warning or critical threshold
+func ExceedsFraction(f float64) string {
+	return ExceedsCompare(float64ToString(f))
+}
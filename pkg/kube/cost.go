@@ -0,0 +1,309 @@
+package kube
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// defaultGPUResource is the extended resource priced as GPU
+	defaultGPUResource = "nvidia.com/gpu"
+
+	gib = 1 << 30
+)
+
+// UnitPrices are the hourly prices of one CPU core, one GiB of memory and one GPU
+type UnitPrices struct {
+	CPU    float64 `json:"cpu" yaml:"cpu"`
+	Memory float64 `json:"memory" yaml:"memory"`
+	GPU    float64 `json:"gpu" yaml:"gpu"`
+}
+
+// PriceTable prices the nodes by the value of a node label, the instance type
+// unless Label says otherwise. Nodes without a price use Default when set.
+type PriceTable struct {
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	// PoolLabel groups the nodes into pools for the idle cost
+	PoolLabel string `json:"poolLabel,omitempty" yaml:"poolLabel,omitempty"`
+	// GPUResource is the extended resource priced as GPU, nvidia.com/gpu by default
+	GPUResource string                `json:"gpuResource,omitempty" yaml:"gpuResource,omitempty"`
+	Currency    string                `json:"currency,omitempty" yaml:"currency,omitempty"`
+	Default     *UnitPrices           `json:"default,omitempty" yaml:"default,omitempty"`
+	Prices      map[string]UnitPrices `json:"prices" yaml:"prices"`
+}
+
+// LoadPriceTable reads a price table file, see PriceTable
+func LoadPriceTable(filename string) (*PriceTable, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	table := &PriceTable{}
+	if err := yaml.UnmarshalStrict(raw, table); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse price table %s", filename)
+	}
+	if len(table.Prices) == 0 && table.Default == nil {
+		return nil, fmt.Errorf("price table %s has neither prices nor a default", filename)
+	}
+	if len(table.Label) == 0 {
+		table.Label = v1.LabelInstanceTypeStable
+	}
+	if len(table.GPUResource) == 0 {
+		table.GPUResource = defaultGPUResource
+	}
+	return table, nil
+}
+
+// Scale multiplies every price, e.g. by 730 to get monthly costs
+func (t *PriceTable) Scale(hours float64) {
+	for key, p := range t.Prices {
+		t.Prices[key] = p.scale(hours)
+	}
+	if t.Default != nil {
+		scaled := t.Default.scale(hours)
+		t.Default = &scaled
+	}
+}
+
+func (p UnitPrices) scale(hours float64) UnitPrices {
+	return UnitPrices{CPU: p.CPU * hours, Memory: p.Memory * hours, GPU: p.GPU * hours}
+}
+
+// prices returns the unit prices of the node, false when it has none
+func (t *PriceTable) prices(node *v1.Node) (UnitPrices, bool) {
+	if p, ok := t.Prices[node.Labels[t.Label]]; ok {
+		return p, true
+	}
+	if t.Default != nil {
+		return *t.Default, true
+	}
+	return UnitPrices{}, false
+}
+
+// PodCost is the cost attributed to a pod, each resource billed by the larger
+// of its requests and its usage
+type PodCost struct {
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+	NodeName  string `json:"nodeName" yaml:"nodeName"`
+	Pool      string `json:"pool" yaml:"pool"`
+	// Workload is namespace/kind/name, see PodWorkload
+	Workload string            `json:"workload" yaml:"workload"`
+	Labels   map[string]string `json:"-" yaml:"-"`
+	CPU      float64           `json:"cpu" yaml:"cpu"`
+	Memory   float64           `json:"memory" yaml:"memory"`
+	GPU      float64           `json:"gpu" yaml:"gpu"`
+	Total    float64           `json:"total" yaml:"total"`
+}
+
+// PoolCost is the cost of a node pool split into what the pods use and the
+// idle rest
+type PoolCost struct {
+	Pool         string  `json:"pool" yaml:"pool"`
+	Nodes        int     `json:"nodes" yaml:"nodes"`
+	Cost         float64 `json:"cost" yaml:"cost"`
+	Allocated    float64 `json:"allocated" yaml:"allocated"`
+	Idle         float64 `json:"idle" yaml:"idle"`
+	IdleFraction float64 `json:"idleFraction" yaml:"idleFraction"`
+}
+
+// CostReport holds the cost of every pod and node pool. Unpriced lists the
+// nodes the price table has no price for, they and their pods cost nothing.
+type CostReport struct {
+	Currency string     `json:"currency,omitempty" yaml:"currency,omitempty"`
+	Pods     []PodCost  `json:"pods" yaml:"pods"`
+	Pools    []PoolCost `json:"pools" yaml:"pools"`
+	Unpriced []string   `json:"unpriced,omitempty" yaml:"unpriced,omitempty"`
+}
+
+// GetCosts attributes the cost of the nodes to their active pods. The node
+// cost is the unit prices times its allocatable, what the pods do not account
+// for is idle.
+func (k *KubeClient) GetCosts(table *PriceTable, selector labels.Selector) (*CostReport, error) {
+	nodes, err := k.GetNodes("", selector)
+	if err != nil {
+		return nil, err
+	}
+	podMetrics, err := k.GetPodMetricsFromMetricsAPI("", labels.Everything(), fields.Everything())
+	if err != nil {
+		return nil, err
+	}
+	podMetricsByName := make(map[string]metricsapi.PodMetrics)
+	for _, m := range podMetrics.Items {
+		podMetricsByName[m.Namespace+"/"+m.Name] = m
+	}
+
+	report := &CostReport{Currency: table.Currency, Pods: []PodCost{}, Pools: []PoolCost{}}
+	pools := map[string]*PoolCost{}
+	gpuResource := v1.ResourceName(table.GPUResource)
+	for _, node := range nodes {
+		pool := none
+		if len(table.PoolLabel) > 0 && len(node.Labels[table.PoolLabel]) > 0 {
+			pool = node.Labels[table.PoolLabel]
+		}
+		p, ok := pools[pool]
+		if !ok {
+			p = &PoolCost{Pool: pool}
+			pools[pool] = p
+		}
+		p.Nodes++
+		// the pods of a node without a price are still listed, at no cost
+		prices, ok := table.prices(&node)
+		if !ok {
+			report.Unpriced = append(report.Unpriced, node.Name)
+		}
+		activePodsList, err := k.GetActivePodByNodename(node)
+		if err != nil {
+			return nil, err
+		}
+		noderesource, err := getNodeAllocatedResources(node, activePodsList, &metricsapi.NodeMetricsList{})
+		if err != nil {
+			return nil, err
+		}
+		capacity := NodeCapacity(&node)
+		nodeCost := prices.cost(noderesource.CPUCapacity.MilliValue(), noderesource.MemoryCapacity.Value(),
+			NewGpuResource(gpuResource, &capacity).Value())
+
+		var allocated float64
+		for i := range activePodsList.Items {
+			pod := &activePodsList.Items[i]
+			podmetric := podMetricsByName[pod.Namespace+"/"+pod.Name]
+			podresource, err := getPodAllocatedResources(pod, &podmetric)
+			if err != nil {
+				return nil, err
+			}
+			reqs, _, err := PodRequestsAndLimits(pod)
+			if err != nil {
+				return nil, err
+			}
+			cost := PodCost{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+				NodeName:  node.Name,
+				Pool:      pool,
+				Workload:  PodWorkload(&PodsResources{Name: pod.Name, Namespace: pod.Namespace, Owner: podOwner(pod), Labels: pod.Labels}),
+				Labels:    pod.Labels,
+				CPU:       prices.cpuCost(max(podresource.CPURequests.MilliValue(), podresource.CPUUsages.MilliValue())),
+				Memory:    prices.memoryCost(max(podresource.MemoryRequests.Value(), podresource.MemoryUsages.Value())),
+				GPU:       prices.gpuCost(NewGpuResource(gpuResource, &reqs).Value()),
+			}
+			cost.Total = cost.CPU + cost.Memory + cost.GPU
+			allocated += cost.Total
+			report.Pods = append(report.Pods, cost)
+		}
+		p.Cost += nodeCost
+		p.Allocated += allocated
+		p.Idle += math.Max(nodeCost-allocated, 0)
+	}
+	for _, p := range pools {
+		if p.Cost > 0 {
+			p.IdleFraction = math.Round(p.Idle/p.Cost*10000) / 100
+		}
+		report.Pools = append(report.Pools, *p)
+	}
+	sort.Slice(report.Pools, func(i, j int) bool { return report.Pools[i].Pool < report.Pools[j].Pool })
+	sort.Strings(report.Unpriced)
+	return report, nil
+}
+
+func (p UnitPrices) cpuCost(millicores int64) float64 {
+	return float64(millicores) / 1000 * p.CPU
+}
+
+func (p UnitPrices) memoryCost(bytes int64) float64 {
+	return float64(bytes) / gib * p.Memory
+}
+
+func (p UnitPrices) gpuCost(gpus int64) float64 {
+	return float64(gpus) * p.GPU
+}
+
+func (p UnitPrices) cost(millicores, bytes, gpus int64) float64 {
+	return p.cpuCost(millicores) + p.memoryCost(bytes) + p.gpuCost(gpus)
+}
+
+// CostItem is the cost of the pods sharing a roll-up key
+type CostItem struct {
+	Key    string  `json:"key" yaml:"key"`
+	Pods   int     `json:"pods" yaml:"pods"`
+	CPU    float64 `json:"cpu" yaml:"cpu"`
+	Memory float64 `json:"memory" yaml:"memory"`
+	GPU    float64 `json:"gpu" yaml:"gpu"`
+	Total  float64 `json:"total" yaml:"total"`
+}
+
+// CostRollUpLabelPrefix rolls the costs up by the value of a pod label, e.g.
+// label=team
+const CostRollUpLabelPrefix = "label="
+
+// ValidateCostRollUp checks a --by value of kr cost
+func ValidateCostRollUp(by string) error {
+	switch by {
+	case "namespace", "workload", "pod", "pool":
+		return nil
+	}
+	if key := strings.TrimPrefix(by, CostRollUpLabelPrefix); key != by && len(key) > 0 {
+		return nil
+	}
+	return fmt.Errorf("unknown roll-up %q, allowed values: namespace, workload, pod, pool, label=<key>", by)
+}
+
+// CostRollUpKey returns the key of a pod cost for a --by value
+func CostRollUpKey(c *PodCost, by string) string {
+	switch by {
+	case "namespace":
+		return c.Namespace
+	case "workload":
+		return c.Workload
+	case "pod":
+		return c.Namespace + "/" + c.Name
+	case "pool":
+		return c.Pool
+	}
+	if value := c.Labels[strings.TrimPrefix(by, CostRollUpLabelPrefix)]; len(value) > 0 {
+		return value
+	}
+	return none
+}
+
+// RollUpCosts sums the pod costs per roll-up key, the most expensive first
+func RollUpCosts(pods []PodCost, by string) []CostItem {
+	byKey := map[string]*CostItem{}
+	var items []*CostItem
+	for i := range pods {
+		c := &pods[i]
+		key := CostRollUpKey(c, by)
+		item, ok := byKey[key]
+		if !ok {
+			item = &CostItem{Key: key}
+			byKey[key] = item
+			items = append(items, item)
+		}
+		item.Pods++
+		item.CPU += c.CPU
+		item.Memory += c.Memory
+		item.GPU += c.GPU
+		item.Total += c.Total
+	}
+	rolled := make([]CostItem, 0, len(items))
+	for _, item := range items {
+		rolled = append(rolled, *item)
+	}
+	sort.SliceStable(rolled, func(i, j int) bool {
+		if rolled[i].Total != rolled[j].Total {
+			return rolled[i].Total > rolled[j].Total
+		}
+		return rolled[i].Key < rolled[j].Key
+	})
+	return rolled
+}
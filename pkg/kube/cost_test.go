@@ -0,0 +1,91 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsV1beta1api "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func TestGetCosts(t *testing.T) {
+	nodeLabels := func(instanceType, pool string) map[string]string {
+		return map[string]string{corev1.LabelInstanceTypeStable: instanceType, "pool": pool}
+	}
+	k := NewSnapshotClient(&Snapshot{
+		Nodes: []corev1.Node{
+			// 4 cores and 8GiB cost 4 + 4
+			*testNode("n1", "4", "8Gi", nodeLabels("large", "a")),
+			// 1 core costs 1, its memory is free
+			*testNode("n2", "1", "1Gi", nodeLabels("small", "b")),
+			*testNode("n3", "2", "2Gi", nodeLabels("unknown", "b")),
+		},
+		Pods: []corev1.Pod{
+			*testPod("web", "n1", "1", "1Gi"),
+			*testPod("api", "n1", "500m", "2Gi"),
+			*testPod("hog", "n2", "1", "1Gi"),
+			*testPod("batch", "n3", "1", "1Gi"),
+		},
+		PodMetrics: []metricsV1beta1api.PodMetrics{
+			*testPodMetrics("web", "2", "512Mi"),
+			*testPodMetrics("api", "100m", "3Gi"),
+			*testPodMetrics("hog", "3", "1Gi"),
+			*testPodMetrics("batch", "2", "2Gi"),
+		},
+	})
+	table := &PriceTable{
+		Label:       corev1.LabelInstanceTypeStable,
+		PoolLabel:   "pool",
+		GPUResource: defaultGPUResource,
+		Prices: map[string]UnitPrices{
+			"large": {CPU: 1, Memory: 0.5},
+			"small": {CPU: 1},
+		},
+	}
+	report, err := k.GetCosts(table, labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pods := map[string]PodCost{}
+	for _, c := range report.Pods {
+		pods[c.Name] = c
+	}
+	tests := []struct {
+		pod         string
+		cpu, memory float64
+	}{
+		// each resource is billed by the larger of its requests and its usage
+		{"web", 2, 0.5},
+		{"api", 0.5, 1.5},
+		{"hog", 3, 0},
+		// the pods of an unpriced node are listed at no cost
+		{"batch", 0, 0},
+	}
+	for _, tt := range tests {
+		c, ok := pods[tt.pod]
+		if !ok {
+			t.Errorf("GetCosts() has no cost for pod %s", tt.pod)
+			continue
+		}
+		if c.CPU != tt.cpu || c.Memory != tt.memory || c.Total != tt.cpu+tt.memory {
+			t.Errorf("GetCosts() cost of %s = %+v, want cpu %v and memory %v", tt.pod, c, tt.cpu, tt.memory)
+		}
+	}
+	if len(report.Pods) != len(tests) {
+		t.Errorf("GetCosts() has %d pod costs, want %d", len(report.Pods), len(tests))
+	}
+
+	wantPools := []PoolCost{
+		{Pool: "a", Nodes: 1, Cost: 8, Allocated: 4.5, Idle: 3.5, IdleFraction: 43.75},
+		// hog uses more than its node costs, the idle cost does not go negative
+		{Pool: "b", Nodes: 2, Cost: 1, Allocated: 3, Idle: 0, IdleFraction: 0},
+	}
+	if !reflect.DeepEqual(report.Pools, wantPools) {
+		t.Errorf("GetCosts() pools = %+v, want %+v", report.Pools, wantPools)
+	}
+	if want := []string{"n3"}; !reflect.DeepEqual(report.Unpriced, want) {
+		t.Errorf("GetCosts() unpriced = %v, want %v", report.Unpriced, want)
+	}
+}
@@ -0,0 +1,215 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// Delta statuses
+const (
+	DeltaAdded   = "Added"
+	DeltaRemoved = "Removed"
+	DeltaChanged = "Changed"
+)
+
+// ResourceDelta is the change of a node, node group or workload between two
+// reports, after minus before. CPU values are in millicores and memory values
+// in bytes.
+type ResourceDelta struct {
+	Key    string `json:"key" yaml:"key"`
+	Status string `json:"status" yaml:"status"`
+
+	CPUUsages   int64 `json:"cpuUsages" yaml:"cpuUsages"`
+	CPURequests int64 `json:"cpuRequests" yaml:"cpuRequests"`
+	CPULimits   int64 `json:"cpuLimits" yaml:"cpuLimits"`
+	CPUCapacity int64 `json:"cpuCapacity" yaml:"cpuCapacity"`
+
+	MemoryUsages   int64 `json:"memoryUsages" yaml:"memoryUsages"`
+	MemoryRequests int64 `json:"memoryRequests" yaml:"memoryRequests"`
+	MemoryLimits   int64 `json:"memoryLimits" yaml:"memoryLimits"`
+	MemoryCapacity int64 `json:"memoryCapacity" yaml:"memoryCapacity"`
+
+	Pods int `json:"pods" yaml:"pods"`
+}
+
+func (d *ResourceDelta) add(o ResourceDelta, sign int64) {
+	d.CPUUsages += sign * o.CPUUsages
+	d.CPURequests += sign * o.CPURequests
+	d.CPULimits += sign * o.CPULimits
+	d.CPUCapacity += sign * o.CPUCapacity
+	d.MemoryUsages += sign * o.MemoryUsages
+	d.MemoryRequests += sign * o.MemoryRequests
+	d.MemoryLimits += sign * o.MemoryLimits
+	d.MemoryCapacity += sign * o.MemoryCapacity
+	d.Pods += int(sign) * o.Pods
+}
+
+func (d *ResourceDelta) zero() bool {
+	return *d == ResourceDelta{Key: d.Key, Status: d.Status}
+}
+
+// deltaSortKeys maps the --sortBy keys of kr diff to the magnitude of a change
+var deltaSortKeys = map[string]func(d *ResourceDelta) int64{
+	"cpu-usage":       func(d *ResourceDelta) int64 { return d.CPUUsages },
+	"cpu-requests":    func(d *ResourceDelta) int64 { return d.CPURequests },
+	"cpu-limits":      func(d *ResourceDelta) int64 { return d.CPULimits },
+	"cpu-capacity":    func(d *ResourceDelta) int64 { return d.CPUCapacity },
+	"memory-usage":    func(d *ResourceDelta) int64 { return d.MemoryUsages },
+	"memory-requests": func(d *ResourceDelta) int64 { return d.MemoryRequests },
+	"memory-limits":   func(d *ResourceDelta) int64 { return d.MemoryLimits },
+	"memory-capacity": func(d *ResourceDelta) int64 { return d.MemoryCapacity },
+	"pods":            func(d *ResourceDelta) int64 { return int64(d.Pods) },
+}
+
+// DeltaSortKeys returns the keys accepted by SortDeltas
+func DeltaSortKeys() []string {
+	return sortKeys(deltaSortKeys)
+}
+
+// ValidateDeltaSortKey returns an error when by is not a diff sort key
+func ValidateDeltaSortKey(by string) error {
+	if _, ok := deltaSortKeys[normalizeSortKey(by)]; !ok {
+		return fmt.Errorf("unknown sort key %q, allowed values: %s", by, strings.Join(DeltaSortKeys(), ", "))
+	}
+	return nil
+}
+
+// SortDeltas puts the biggest movers first, growth and shrinkage alike
+func SortDeltas(deltas []ResourceDelta, by string) error {
+	if err := ValidateDeltaSortKey(by); err != nil {
+		return err
+	}
+	key := deltaSortKeys[normalizeSortKey(by)]
+	abs := func(v int64) int64 {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	sort.SliceStable(deltas, func(i, j int) bool {
+		a, b := abs(key(&deltas[i])), abs(key(&deltas[j]))
+		if a != b {
+			return a > b
+		}
+		return deltas[i].Key < deltas[j].Key
+	})
+	return nil
+}
+
+// diff matches the aggregated rows of both reports by key, dropping the keys
+// that did not change
+func diff(before, after map[string]ResourceDelta) []ResourceDelta {
+	var deltas []ResourceDelta
+	for key, a := range after {
+		d := ResourceDelta{Key: key, Status: DeltaChanged}
+		d.add(a, 1)
+		if b, ok := before[key]; ok {
+			d.add(b, -1)
+		} else {
+			d.Status = DeltaAdded
+		}
+		if !d.zero() || d.Status != DeltaChanged {
+			deltas = append(deltas, d)
+		}
+	}
+	for key, b := range before {
+		if _, ok := after[key]; !ok {
+			d := ResourceDelta{Key: key, Status: DeltaRemoved}
+			d.add(b, -1)
+			deltas = append(deltas, d)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Key < deltas[j].Key })
+	return deltas
+}
+
+// clusterKey prefixes the key with the cluster of the row, so that rows of
+// different contexts do not merge
+func clusterKey(cluster, key string) string {
+	if len(cluster) == 0 {
+		return key
+	}
+	return cluster + "/" + key
+}
+
+// nodeDeltaKey returns the node name, or the value of the group label
+func nodeDeltaKey(r *NodeResources, groupBy string) string {
+	if len(groupBy) == 0 {
+		return clusterKey(r.Cluster, r.NodeName)
+	}
+	if value, ok := r.Labels[groupBy]; ok {
+		return clusterKey(r.Cluster, value)
+	}
+	return clusterKey(r.Cluster, none)
+}
+
+func sumNodes(rows []NodeResources, groupBy string) map[string]ResourceDelta {
+	sums := map[string]ResourceDelta{}
+	for i := range rows {
+		r := &rows[i]
+		key := nodeDeltaKey(r, groupBy)
+		d := sums[key]
+		d.add(ResourceDelta{
+			CPUUsages: r.CPUUsages, CPURequests: r.CPURequests, CPULimits: r.CPULimits, CPUCapacity: r.CPUCapacity,
+			MemoryUsages: r.MemoryUsages, MemoryRequests: r.MemoryRequests, MemoryLimits: r.MemoryLimits, MemoryCapacity: r.MemoryCapacity,
+			Pods: r.AllocatedPods,
+		}, 1)
+		sums[key] = d
+	}
+	return sums
+}
+
+// DiffNodes compares two node reports per node, or per value of the groupBy
+// label when it is set
+func DiffNodes(before, after []NodeResources, groupBy string) []ResourceDelta {
+	return diff(sumNodes(before, groupBy), sumNodes(after, groupBy))
+}
+
+// PodWorkload returns the workload a pod belongs to as namespace/kind/name.
+// ReplicaSets named after the pod-template-hash label of the pod are folded
+// into their Deployment so that rollouts compare.
+func PodWorkload(r *PodsResources) string {
+	owner := r.Owner
+	if len(owner) == 0 {
+		owner = "Pod/" + r.Name
+	}
+	if name := strings.TrimPrefix(owner, "ReplicaSet/"); name != owner {
+		if hash := r.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; len(hash) > 0 && strings.HasSuffix(name, "-"+hash) {
+			owner = "Deployment/" + strings.TrimSuffix(name, "-"+hash)
+		}
+	}
+	return r.Namespace + "/" + owner
+}
+
+func sumPods(rows []PodsResources) map[string]ResourceDelta {
+	sums := map[string]ResourceDelta{}
+	for i := range rows {
+		r := &rows[i]
+		key := clusterKey(r.Cluster, PodWorkload(r))
+		d := sums[key]
+		d.add(ResourceDelta{
+			CPUUsages: r.CPUUsages, CPURequests: r.CPURequests, CPULimits: r.CPULimits,
+			MemoryUsages: r.MemoryUsages, MemoryRequests: r.MemoryRequests, MemoryLimits: r.MemoryLimits,
+			Pods: 1,
+		}, 1)
+		sums[key] = d
+	}
+	return sums
+}
+
+// DiffPods compares two pod reports per workload
+func DiffPods(before, after []PodsResources) []ResourceDelta {
+	return diff(sumPods(before), sumPods(after))
+}
+
+// SumDeltas totals the changes
+func SumDeltas(deltas []ResourceDelta) ResourceDelta {
+	var total ResourceDelta
+	for _, d := range deltas {
+		total.add(d, 1)
+	}
+	return total
+}
@@ -0,0 +1,144 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffNodes(t *testing.T) {
+	before := []NodeResources{
+		{NodeName: "n1", CPURequests: 500, CPUCapacity: 4000, AllocatedPods: 5, Labels: map[string]string{"pool": "a"}},
+		{NodeName: "n2", CPURequests: 100, CPUCapacity: 2000, AllocatedPods: 1, Labels: map[string]string{"pool": "a"}},
+		{NodeName: "n3", CPURequests: 300, CPUCapacity: 2000, AllocatedPods: 3},
+	}
+	after := []NodeResources{
+		{NodeName: "n1", CPURequests: 500, CPUCapacity: 4000, AllocatedPods: 5, Labels: map[string]string{"pool": "a"}},
+		{NodeName: "n2", CPURequests: 700, CPUCapacity: 2000, AllocatedPods: 4, Labels: map[string]string{"pool": "a"}},
+		{NodeName: "n4", CPURequests: 200, CPUCapacity: 8000, AllocatedPods: 2, Labels: map[string]string{"pool": "b"}},
+	}
+	got := DiffNodes(before, after, "")
+	want := []ResourceDelta{
+		{Key: "n2", Status: DeltaChanged, CPURequests: 600, Pods: 3},
+		{Key: "n3", Status: DeltaRemoved, CPURequests: -300, CPUCapacity: -2000, Pods: -3},
+		{Key: "n4", Status: DeltaAdded, CPURequests: 200, CPUCapacity: 8000, Pods: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffNodes() = %+v, want %+v", got, want)
+	}
+
+	got = DiffNodes(before, after, "pool")
+	want = []ResourceDelta{
+		{Key: "<none>", Status: DeltaRemoved, CPURequests: -300, CPUCapacity: -2000, Pods: -3},
+		{Key: "a", Status: DeltaChanged, CPURequests: 600, Pods: 3},
+		{Key: "b", Status: DeltaAdded, CPURequests: 200, CPUCapacity: 8000, Pods: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffNodes(pool) = %+v, want %+v", got, want)
+	}
+
+	// an added key that sums to zero is still reported
+	empty := []NodeResources{{NodeName: "n5"}}
+	got = DiffNodes(nil, empty, "")
+	want = []ResourceDelta{{Key: "n5", Status: DeltaAdded}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffNodes(added empty) = %+v, want %+v", got, want)
+	}
+	if got := DiffNodes(before, before, ""); len(got) != 0 {
+		t.Errorf("DiffNodes(same) = %+v, want no delta", got)
+	}
+
+	// same-named nodes of different contexts are kept apart
+	clusters := []NodeResources{
+		{Cluster: "prod-us", NodeName: "n1", CPURequests: 500},
+		{Cluster: "prod-eu", NodeName: "n1", CPURequests: 200},
+	}
+	got = DiffNodes(clusters[:1], clusters, "")
+	want = []ResourceDelta{{Key: "prod-eu/n1", Status: DeltaAdded, CPURequests: 200}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffNodes(clusters) = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffPods(t *testing.T) {
+	hash := func(h string) map[string]string { return map[string]string{"pod-template-hash": h} }
+	before := []PodsResources{
+		{Name: "web-6c9f-a", Namespace: "default", Owner: "ReplicaSet/web-6c9f", Labels: hash("6c9f"), MemoryRequests: 100},
+		{Name: "web-6c9f-b", Namespace: "default", Owner: "ReplicaSet/web-6c9f", Labels: hash("6c9f"), MemoryRequests: 100},
+		{Name: "debug", Namespace: "default", MemoryRequests: 10},
+	}
+	after := []PodsResources{
+		{Name: "web-7d4b-a", Namespace: "default", Owner: "ReplicaSet/web-7d4b", Labels: hash("7d4b"), MemoryRequests: 150},
+		{Name: "web-7d4b-b", Namespace: "default", Owner: "ReplicaSet/web-7d4b", Labels: hash("7d4b"), MemoryRequests: 150},
+		{Name: "web-7d4b-c", Namespace: "default", Owner: "ReplicaSet/web-7d4b", Labels: hash("7d4b"), MemoryRequests: 150},
+		{Name: "debug", Namespace: "default", MemoryRequests: 10},
+	}
+	got := DiffPods(before, after)
+	want := []ResourceDelta{{Key: "default/Deployment/web", Status: DeltaChanged, MemoryRequests: 250, Pods: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffPods() = %+v, want %+v", got, want)
+	}
+
+	// the same workload in two contexts is two workloads
+	clusters := []PodsResources{
+		{Cluster: "prod-us", Name: "debug", Namespace: "default", MemoryRequests: 10},
+		{Cluster: "prod-eu", Name: "debug", Namespace: "default", MemoryRequests: 10},
+	}
+	got = DiffPods(clusters, clusters[1:])
+	want = []ResourceDelta{{Key: "prod-us/default/Pod/debug", Status: DeltaRemoved, MemoryRequests: -10, Pods: -1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffPods(clusters) = %+v, want %+v", got, want)
+	}
+}
+
+func TestPodWorkload(t *testing.T) {
+	tests := []struct {
+		row  PodsResources
+		want string
+	}{
+		{PodsResources{Name: "web-7d4b-x", Namespace: "ns", Owner: "ReplicaSet/web-7d4b",
+			Labels: map[string]string{"pod-template-hash": "7d4b"}}, "ns/Deployment/web"},
+		// standalone ReplicaSets are kept, whatever their name looks like
+		{PodsResources{Name: "web-frontend-x", Namespace: "ns", Owner: "ReplicaSet/web-frontend"}, "ns/ReplicaSet/web-frontend"},
+		{PodsResources{Name: "web-frontend-x", Namespace: "ns", Owner: "ReplicaSet/web-frontend",
+			Labels: map[string]string{"pod-template-hash": "7d4b"}}, "ns/ReplicaSet/web-frontend"},
+		{PodsResources{Name: "db-0", Namespace: "ns", Owner: "StatefulSet/db"}, "ns/StatefulSet/db"},
+		{PodsResources{Name: "debug", Namespace: "ns"}, "ns/Pod/debug"},
+	}
+	for _, tt := range tests {
+		if got := PodWorkload(&tt.row); got != tt.want {
+			t.Errorf("PodWorkload(%s) = %q, want %q", tt.row.Name, got, tt.want)
+		}
+	}
+}
+
+func TestSortDeltas(t *testing.T) {
+	deltas := []ResourceDelta{
+		{Key: "a", CPURequests: 100, CPUCapacity: 2000},
+		{Key: "b", CPURequests: -500, CPUCapacity: 8000},
+		{Key: "c", CPURequests: 100, CPUCapacity: -4000},
+	}
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		// shrinkage ranks like growth, ties by key
+		{"cpu-requests", []string{"b", "a", "c"}},
+		{"cpu-capacity", []string{"b", "c", "a"}},
+	}
+	for _, tt := range tests {
+		if err := SortDeltas(deltas, tt.by); err != nil {
+			t.Errorf("SortDeltas(%q) failed: %v", tt.by, err)
+			continue
+		}
+		var got []string
+		for _, d := range deltas {
+			got = append(got, d.Key)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SortDeltas(%q) = %v, want %v", tt.by, got, tt.want)
+		}
+	}
+	if err := SortDeltas(deltas, "age"); err == nil {
+		t.Errorf("SortDeltas(%q) succeeded, want an error", "age")
+	}
+}
@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics"
+)
+
+// FitRequest describes the replicas to place. CPU is in millicores and
+// memory in bytes, Spec carries the scheduling constraints.
+type FitRequest struct {
+	Spec     corev1.PodSpec
+	CPU      int64
+	Memory   int64
+	Replicas int
+}
+
+// NodeFit is the room left on a node for the requested replicas
+type NodeFit struct {
+	NodeName   string `json:"nodeName" yaml:"nodeName"`
+	CPUFree    int64  `json:"cpuFree" yaml:"cpuFree"`
+	MemoryFree int64  `json:"memoryFree" yaml:"memoryFree"`
+	PodsFree   int64  `json:"podsFree" yaml:"podsFree"`
+	Fits       int64  `json:"fits" yaml:"fits"`
+	// Reason explains why no replica fits on the node
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// FitReport tells how many replicas fit on every node and cluster-wide
+type FitReport struct {
+	CPU      int64 `json:"cpu" yaml:"cpu"`
+	Memory   int64 `json:"memory" yaml:"memory"`
+	Replicas int   `json:"replicas" yaml:"replicas"`
+	// Fits is the number of replicas the cluster can hold
+	Fits        int64     `json:"fits" yaml:"fits"`
+	Schedulable bool      `json:"schedulable" yaml:"schedulable"`
+	Items       []NodeFit `json:"items" yaml:"items"`
+}
+
+// Fit simulates where the requested replicas would land, from the free
+// allocatable of each node after the requests of its active pods
+func (k *KubeClient) Fit(req FitRequest, selector labels.Selector) (*FitReport, error) {
+	nodes, err := k.GetNodes("", selector)
+	if err != nil {
+		return nil, err
+	}
+	report := &FitReport{CPU: req.CPU, Memory: req.Memory, Replicas: req.Replicas}
+	for _, node := range nodes {
+		activePodsList, err := k.GetActivePodByNodename(node)
+		if err != nil {
+			return nil, err
+		}
+		noderesource, err := getNodeAllocatedResources(node, activePodsList, &metricsapi.NodeMetricsList{})
+		if err != nil {
+			return nil, err
+		}
+		fit, err := nodeFit(&node, noderesource, req)
+		if err != nil {
+			return nil, err
+		}
+		report.Fits += fit.Fits
+		report.Items = append(report.Items, fit)
+	}
+	report.Schedulable = report.Fits >= int64(req.Replicas)
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		if report.Items[i].Fits != report.Items[j].Fits {
+			return report.Items[i].Fits > report.Items[j].Fits
+		}
+		return report.Items[i].NodeName < report.Items[j].NodeName
+	})
+	return report, nil
+}
+
+func nodeFit(node *corev1.Node, noderesource NodeAllocatedResources, req FitRequest) (NodeFit, error) {
+	fit := NodeFit{
+		NodeName:   node.Name,
+		CPUFree:    noderesource.CPUCapacity.MilliValue() - noderesource.CPURequests.MilliValue(),
+		MemoryFree: noderesource.MemoryCapacity.Value() - noderesource.MemoryRequests.Value(),
+		PodsFree:   noderesource.PodCapacity - int64(noderesource.AllocatedPods),
+	}
+	reason, err := unschedulableReason(node, &req.Spec)
+	if err != nil || len(reason) > 0 {
+		fit.Reason = reason
+		return fit, err
+	}
+
+	fit.Fits, fit.Reason = fit.PodsFree, "TooManyPods"
+	if req.CPU > 0 && fit.CPUFree/req.CPU < fit.Fits {
+		fit.Fits, fit.Reason = fit.CPUFree/req.CPU, "InsufficientCPU"
+	}
+	if req.Memory > 0 && fit.MemoryFree/req.Memory < fit.Fits {
+		fit.Fits, fit.Reason = fit.MemoryFree/req.Memory, "InsufficientMemory"
+	}
+	if fit.Fits <= 0 {
+		fit.Fits = 0
+		return fit, nil
+	}
+	fit.Reason = ""
+	return fit, nil
+}
@@ -0,0 +1,100 @@
+package kube
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const reasonOOMKilled = "OOMKilled"
+
+// podOOMKilled counts the OOM kills the pod status still shows. The kubelet
+// only keeps the last termination of a container besides its current state,
+// so earlier OOM kills are not counted.
+func podOOMKilled(pod *corev1.Pod) int32 {
+	var killed int32
+	for _, c := range pod.Status.ContainerStatuses {
+		if t := c.LastTerminationState.Terminated; t != nil && t.Reason == reasonOOMKilled {
+			killed++
+		}
+		if t := c.State.Terminated; t != nil && t.Reason == reasonOOMKilled {
+			killed++
+		}
+	}
+	return killed
+}
+
+// podLastTerminations returns the last termination of every restarted
+// container as name:reason(exitCode)
+func podLastTerminations(pod *corev1.Pod) []string {
+	terminations := []string{}
+	for _, c := range pod.Status.ContainerStatuses {
+		t := c.LastTerminationState.Terminated
+		if t == nil {
+			continue
+		}
+		reason := t.Reason
+		if len(reason) == 0 {
+			reason = "Error"
+		}
+		terminations = append(terminations, fmt.Sprintf("%s:%s(%d)", c.Name, reason, t.ExitCode))
+	}
+	return terminations
+}
+
+// eventTime returns the last time an event was seen
+func eventTime(e *corev1.Event) metav1.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp
+	case !e.EventTime.IsZero():
+		return metav1.NewTime(e.EventTime.Time)
+	}
+	return e.CreationTimestamp
+}
+
+// AddPodWarnings fills the Warnings and LastWarning of the pod rows from the
+// Warning events the API server still retains for them. Events are matched on
+// the pod UID, so a pod recreated under the same name does not inherit the
+// events of its predecessor.
+func (k *KubeClient) AddPodWarnings(rows []PodsResources, namespace string) error {
+	fieldSelector := fields.Set{"type": corev1.EventTypeWarning, "involvedObject.kind": "Pod"}.AsSelector()
+	events, err := k.loader.listEvents(namespace, metav1.ListOptions{FieldSelector: fieldSelector.String()})
+	if err != nil {
+		return err
+	}
+	byUID := make(map[types.UID][]*corev1.Event)
+	// events recorded without the UID of the pod fall back to its name
+	byName := make(map[string][]*corev1.Event)
+	for i := range events.Items {
+		e := &events.Items[i]
+		if len(e.InvolvedObject.UID) > 0 {
+			byUID[e.InvolvedObject.UID] = append(byUID[e.InvolvedObject.UID], e)
+		} else {
+			key := e.InvolvedObject.Namespace + "/" + e.InvolvedObject.Name
+			byName[key] = append(byName[key], e)
+		}
+	}
+	for i := range rows {
+		var last *corev1.Event
+		var lastTime metav1.Time
+		podEvents := append(byUID[types.UID(rows[i].UID)], byName[rows[i].Namespace+"/"+rows[i].Name]...)
+		for _, e := range podEvents {
+			count := e.Count
+			if count < 1 {
+				count = 1
+			}
+			rows[i].Warnings += count
+			if t := eventTime(e); last == nil || lastTime.Before(&t) {
+				last, lastTime = e, t
+			}
+		}
+		if last != nil {
+			rows[i].LastWarning = fmt.Sprintf("%s: %s", last.Reason, last.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package kube
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodOOMKilled(t *testing.T) {
+	terminated := func(reason string) corev1.ContainerState {
+		return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: reason}}
+	}
+	tests := []struct {
+		name     string
+		statuses []corev1.ContainerStatus
+		want     int32
+	}{
+		{"never restarted", []corev1.ContainerStatus{{}}, 0},
+		// the earlier restarts ended in errors, only the last one is an OOM kill
+		{"last termination", []corev1.ContainerStatus{{RestartCount: 50, LastTerminationState: terminated(reasonOOMKilled)}}, 1},
+		{"errors only", []corev1.ContainerStatus{{RestartCount: 3, LastTerminationState: terminated("Error")}}, 0},
+		{"killed again", []corev1.ContainerStatus{{RestartCount: 1, LastTerminationState: terminated(reasonOOMKilled), State: terminated(reasonOOMKilled)}}, 2},
+		{"two containers", []corev1.ContainerStatus{
+			{Name: "app", State: terminated(reasonOOMKilled)},
+			{Name: "sidecar", RestartCount: 4, LastTerminationState: terminated(reasonOOMKilled)},
+		}, 2},
+	}
+	for _, tt := range tests {
+		pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: tt.statuses}}
+		if got := podOOMKilled(pod); got != tt.want {
+			t.Errorf("podOOMKilled(%s) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
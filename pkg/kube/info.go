@@ -0,0 +1,314 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubectl/pkg/util/qos"
+)
+
+const (
+	labelNodeRolePrefix = "node-role.kubernetes.io/"
+	labelNodeRole       = "kubernetes.io/role"
+
+	labelZone             = "topology.kubernetes.io/zone"
+	labelZoneBeta         = "failure-domain.beta.kubernetes.io/zone"
+	labelInstanceType     = "node.kubernetes.io/instance-type"
+	labelInstanceTypeBeta = "beta.kubernetes.io/instance-type"
+
+	none = "<none>"
+)
+
+// pressureConditions are the node conditions reported in wide output
+var pressureConditions = []v1.NodeConditionType{
+	v1.NodeMemoryPressure,
+	v1.NodeDiskPressure,
+	v1.NodePIDPressure,
+}
+
+// nodeRoles returns the roles of a node the way kubectl get nodes does
+func nodeRoles(node *v1.Node) []string {
+	roles := []string{}
+	for k, v := range node.Labels {
+		switch {
+		case strings.HasPrefix(k, labelNodeRolePrefix):
+			if role := strings.TrimPrefix(k, labelNodeRolePrefix); len(role) > 0 {
+				roles = append(roles, role)
+			}
+		case k == labelNodeRole && v != "":
+			roles = append(roles, v)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// labelWithFallback returns the value of the first label that is set
+func labelWithFallback(labels map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := labels[k]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// nodeZone
+func nodeZone(node *v1.Node) string {
+	return labelWithFallback(node.Labels, labelZone, labelZoneBeta)
+}
+
+// nodeInstanceType
+func nodeInstanceType(node *v1.Node) string {
+	return labelWithFallback(node.Labels, labelInstanceType, labelInstanceTypeBeta)
+}
+
+// nodePressureConditions returns the pressure conditions currently reported as true
+func nodePressureConditions(node *v1.Node) []string {
+	conditions := []string{}
+	for _, c := range node.Status.Conditions {
+		for _, t := range pressureConditions {
+			if c.Type == t && c.Status == v1.ConditionTrue {
+				conditions = append(conditions, string(c.Type))
+			}
+		}
+	}
+	return conditions
+}
+
+// nodeAddresses returns the addresses of the given type in the order the
+// kubelet reports them, the primary family first on dual-stack nodes
+func nodeAddresses(node *v1.Node, addressType v1.NodeAddressType) []string {
+	addresses := []string{}
+	for _, a := range node.Status.Addresses {
+		if a.Type == addressType && len(a.Address) > 0 {
+			addresses = append(addresses, a.Address)
+		}
+	}
+	return addresses
+}
+
+// firstOf returns the first non empty value of the lists
+func firstOf(lists ...[]string) string {
+	for _, list := range lists {
+		for _, v := range list {
+			if len(v) > 0 {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// addressTypes are the allowed values of --address-type
+var addressTypes = []v1.NodeAddressType{
+	v1.NodeInternalIP,
+	v1.NodeExternalIP,
+	v1.NodeHostName,
+}
+
+// ValidateAddressType checks a node address type given on the command line
+func ValidateAddressType(addressType string) error {
+	for _, t := range addressTypes {
+		if string(t) == addressType {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown address type %q, allowed values: %s", addressType, strings.Join(AddressTypes(), ", "))
+}
+
+// AddressTypes returns the allowed values of --address-type
+func AddressTypes() []string {
+	types := make([]string, 0, len(addressTypes))
+	for _, t := range addressTypes {
+		types = append(types, string(t))
+	}
+	return types
+}
+
+// Addresses returns the node addresses of the given type
+func (r *NodeResources) Addresses(addressType string) []string {
+	switch v1.NodeAddressType(addressType) {
+	case v1.NodeInternalIP:
+		return r.InternalIPs
+	case v1.NodeExternalIP:
+		return r.ExternalIPs
+	case v1.NodeHostName:
+		if len(r.Hostname) > 0 {
+			return []string{r.Hostname}
+		}
+	}
+	return nil
+}
+
+// nodeStatus renders the readiness of a node the way kubectl get nodes does,
+// e.g. Ready,SchedulingDisabled
+func nodeStatus(node *v1.Node) string {
+	status := "Unknown"
+	for _, c := range node.Status.Conditions {
+		if c.Type != v1.NodeReady {
+			continue
+		}
+		switch c.Status {
+		case v1.ConditionTrue:
+			status = string(v1.NodeReady)
+		case v1.ConditionFalse:
+			status = "NotReady"
+		}
+	}
+	if node.Spec.Unschedulable {
+		status += ",SchedulingDisabled"
+	}
+	return status
+}
+
+// nodeTaintEffects returns the distinct effects of the node taints, sorted
+func nodeTaintEffects(node *v1.Node) []string {
+	effects := []string{}
+	seen := map[v1.TaintEffect]bool{}
+	for _, t := range node.Spec.Taints {
+		if !seen[t.Effect] {
+			seen[t.Effect] = true
+			effects = append(effects, string(t.Effect))
+		}
+	}
+	sort.Strings(effects)
+	return effects
+}
+
+// podQOSClass prefers the class reported by the kubelet and computes it from
+// the pod spec when the status has not been populated yet
+func podQOSClass(pod *v1.Pod) v1.PodQOSClass {
+	if len(pod.Status.QOSClass) > 0 {
+		return pod.Status.QOSClass
+	}
+	return qos.GetPodQOS(pod)
+}
+
+// podRestarts sums the restart counts of all containers of the pod
+func podRestarts(pod *v1.Pod) int32 {
+	var restarts int32
+	for _, c := range pod.Status.ContainerStatuses {
+		restarts += c.RestartCount
+	}
+	return restarts
+}
+
+// podPendingReason explains why a Pending pod does not run: the reason of its
+// failed PodScheduled condition, else the first waiting container
+func podPendingReason(pod *v1.Pod) (string, string) {
+	if pod.Status.Phase != v1.PodPending {
+		return "", ""
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse {
+			return c.Reason, c.Message
+		}
+	}
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, c := range statuses {
+			if c.State.Waiting != nil && len(c.State.Waiting.Reason) > 0 {
+				return c.State.Waiting.Reason, c.State.Waiting.Message
+			}
+		}
+	}
+	return "", ""
+}
+
+// podOwner returns the controller of the pod as kind/name
+func podOwner(pod *v1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+		}
+	}
+	if len(pod.OwnerReferences) > 0 {
+		return fmt.Sprintf("%s/%s", pod.OwnerReferences[0].Kind, pod.OwnerReferences[0].Name)
+	}
+	return ""
+}
+
+// OrNone prints <none> for an empty value in tabular output
+func OrNone(s string) string {
+	if len(s) == 0 {
+		return none
+	}
+	return s
+}
+
+// JoinOrNone joins a list for tabular output, printing <none> when it is empty
+func JoinOrNone(list []string) string {
+	if len(list) == 0 {
+		return none
+	}
+	return strings.Join(list, ",")
+}
+
+// taintEffects are the allowed values of --exclude-tainted
+var taintEffects = []v1.TaintEffect{
+	v1.TaintEffectNoSchedule,
+	v1.TaintEffectPreferNoSchedule,
+	v1.TaintEffectNoExecute,
+}
+
+// ValidateTaintEffect checks a taint effect given on the command line
+func ValidateTaintEffect(effect string) error {
+	for _, e := range taintEffects {
+		if string(e) == effect {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown taint effect %q, allowed values: NoSchedule, PreferNoSchedule, NoExecute", effect)
+}
+
+// podPhases are the allowed values of --phase
+var podPhases = []v1.PodPhase{
+	v1.PodPending,
+	v1.PodRunning,
+	v1.PodSucceeded,
+	v1.PodFailed,
+	v1.PodUnknown,
+}
+
+// ValidatePodPhase checks a pod phase given on the command line
+func ValidatePodPhase(phase string) error {
+	for _, p := range podPhases {
+		if string(p) == phase {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown pod phase %q, allowed values: Pending, Running, Succeeded, Failed, Unknown", phase)
+}
+
+// FilterNodes drops the nodes that can not receive workloads: the cordoned
+// and not ready ones when schedulableOnly is set, and the nodes carrying a
+// taint with one of the excluded effects
+func FilterNodes(rows []NodeResources, schedulableOnly bool, excludeEffects []string) []NodeResources {
+	if !schedulableOnly && len(excludeEffects) == 0 {
+		return rows
+	}
+	var kept []NodeResources
+	for _, r := range rows {
+		if schedulableOnly && (!r.Ready || !r.Schedulable) {
+			continue
+		}
+		if hasAny(r.TaintEffects, excludeEffects) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+func hasAny(list, values []string) bool {
+	for _, v := range values {
+		for _, l := range list {
+			if l == v {
+				return true
+			}
+		}
+	}
+	return false
+}
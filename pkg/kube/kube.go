@@ -1,21 +1,18 @@
 package kube
 
 import (
-	"context"
 	"log"
-	"os"
-	"path/filepath"
 	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/kubectl/pkg/metricsutil"
 	metricsapi "k8s.io/metrics/pkg/apis/metrics"
 	metricsV1beta1api "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -26,25 +23,43 @@ type ClientConfig struct {
 	Burst      int
 	KubeCtx    string
 	KubeConfig string
+	// Snapshot is a file written by kr snapshot save, read instead of the API
+	Snapshot string
 }
 
 type KubeClient struct {
-	apiClient     kubernetes.Interface
-	metricsClient *metrics.Clientset
+	loader loader
 }
 
 func NewKubeClient(cc *ClientConfig) (*KubeClient, error) {
+	if len(cc.Snapshot) > 0 {
+		snapshot, err := LoadSnapshot(cc.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		return NewSnapshotClient(snapshot), nil
+	}
 	client, metricsClient, err := New(cc)
 	if err != nil {
 		return nil, err
 	}
 
 	return &KubeClient{
-		apiClient:     client,
-		metricsClient: metricsClient,
+		loader: &apiLoader{apiClient: client, metricsClient: metricsClient},
 	}, nil
 }
 
+// NewSnapshotClient returns a client serving the objects of a snapshot
+func NewSnapshotClient(snapshot *Snapshot) *KubeClient {
+	return &KubeClient{loader: &snapshotLoader{snapshot: snapshot}}
+}
+
+// Now returns the time the reports are computed at, the capture time when
+// reading a snapshot so that its ages do not change over time
+func (k *KubeClient) Now() time.Time {
+	return k.loader.now()
+}
+
 // New returns a kubernetes client.
 // It tries first with in-cluster config, if it fails it will try with out-of-cluster config.
 func New(cc *ClientConfig) (client kubernetes.Interface, metricsClient *metrics.Clientset, err error) {
@@ -62,32 +77,11 @@ func New(cc *ClientConfig) (client kubernetes.Interface, metricsClient *metrics.
 
 // NewFromConfig returns a new out-of-cluster kubernetes client.
 func NewFromConfig(cc *ClientConfig) (client kubernetes.Interface, metricsClient *metrics.Clientset, err error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if cc.KubeConfig == "" {
-		cc.KubeConfig = os.Getenv("KUBECONFIG")
-		if cc.KubeConfig == "" {
-			dir, err := os.UserHomeDir()
-			if err != nil {
-				return nil, nil, err
-			}
-			cc.KubeConfig = filepath.Join(dir, ".kube", "config")
-		}
-	}
-
-	loadingRules.ExplicitPath = cc.KubeConfig
-
-	var config *rest.Config
-
-	if cc.KubeCtx != "" {
-		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			loadingRules,
-			&clientcmd.ConfigOverrides{CurrentContext: cc.KubeCtx},
-		).ClientConfig()
-	} else {
-		// use the current context in kubeconfig
-		config, err = clientcmd.BuildConfigFromFlags("", cc.KubeConfig)
-	}
-
+	// use the current context in kubeconfig unless one is given
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules(cc.KubeConfig),
+		&clientcmd.ConfigOverrides{CurrentContext: cc.KubeCtx},
+	).ClientConfig()
 	if err != nil {
 		return
 	}
@@ -110,6 +104,30 @@ func NewFromConfig(cc *ClientConfig) (client kubernetes.Interface, metricsClient
 	return client, metricsClient, nil
 }
 
+// loadingRules reads the given kubeconfig file, or else merges the files
+// listed in $KUBECONFIG, or else reads ~/.kube/config, the way kubectl does
+func loadingRules(kubeconfig string) *clientcmd.ClientConfigLoadingRules {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	return rules
+}
+
+// Contexts lists the context names of the kubeconfig, sorted by name
+func Contexts(kubeconfig string) ([]string, error) {
+	config, err := loadingRules(kubeconfig).Load()
+	if err != nil {
+		return nil, err
+	}
+	var contexts []string
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
 // NewInCluster returns a new in-cluster kubernetes client.
 func NewInCluster(cc *ClientConfig) (client kubernetes.Interface, metricsClient *metrics.Clientset, err error) {
 	// creates the in-cluster config
@@ -150,13 +168,13 @@ func (cc *ClientConfig) apply(config *rest.Config) {
 func (k *KubeClient) GetNodes(resourceName string, selector labels.Selector) (map[string]corev1.Node, error) {
 	nodes := make(map[string]corev1.Node)
 	if len(resourceName) > 0 {
-		node, err := k.apiClient.CoreV1().Nodes().Get(context.TODO(), resourceName, metav1.GetOptions{})
+		node, err := k.loader.getNode(resourceName)
 		if err != nil {
 			return nil, err
 		}
 		nodes[node.Name] = *node
 	} else {
-		nodeList, err := k.apiClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
+		nodeList, err := k.loader.listNodes(metav1.ListOptions{
 			LabelSelector: selector.String(),
 		})
 		if err != nil {
@@ -178,166 +196,303 @@ func (k *KubeClient) GetActivePodByNodename(node corev1.Node) (*corev1.PodList,
 	if err != nil {
 		return nil, err
 	}
-	activePods, err := k.apiClient.CoreV1().Pods(corev1.NamespaceAll).List(context.TODO(), metav1.ListOptions{FieldSelector: fieldSelector.String()})
+	activePods, err := k.loader.listPods(corev1.NamespaceAll, metav1.ListOptions{FieldSelector: fieldSelector.String()})
 	if err != nil {
 		return nil, err
 	}
 	return activePods, err
 }
 
+// GetActivePods lists the pods of a namespace that are neither Succeeded nor
+// Failed, all namespaces when namespace is empty
+func (k *KubeClient) GetActivePods(namespace string) (*corev1.PodList, error) {
+	fieldSelector, err := fields.ParseSelector("status.phase!=" + string(corev1.PodSucceeded) +
+		",status.phase!=" + string(corev1.PodFailed))
+	if err != nil {
+		return nil, err
+	}
+	return k.loader.listPods(namespace, metav1.ListOptions{FieldSelector: fieldSelector.String()})
+}
+
 // GetActivePodByPodname
 func (k *KubeClient) GetPodByPodname(podName string, namespace string) (*corev1.Pod, error) {
-	pod, err := k.apiClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	pod, err := k.loader.getPod(namespace, podName)
 	if err != nil {
 		return nil, err
 	}
 	return pod, err
 }
 
+// NodeResources is the computed resource overview of a single node. CPU values
+// are in millicores and memory values in bytes.
 type NodeResources struct {
-	NodeName            string `json:"nodeName" yaml:"nodeName"`
-	NodeIP              string `json:"nodeIP" yaml:"nodeIP"`
-	CPUUsages           string `json:"cpuUsages" yaml:"cpuUsages"`
-	CPURequests         string `json:"cpuRequests" yaml:"cpuRequests"`
-	CPULimits           string `json:"cpuLimits" yaml:"cpuLimits"`
-	CPUCapacity         string `json:"cpuCapacity" yaml:"cpuCapacity"`
-	CPURequestsFraction string `json:"cpuRequestsFraction" yaml:"cpuRequestsFraction"`
-	CPULimitsFraction   string `json:"cpuLimitsFraction" yaml:"cpuLimitsFraction"`
-
-	MemoryUsages           string `json:"memoryUsages" yaml:"memoryUsages"`
-	MemoryRequests         string `json:"memoryRequests" yaml:"memoryRequests"`
-	MemoryLimits           string `json:"memoryLimits" yaml:"memoryLimits"`
-	MemoryCapacity         string `json:"memoryCapacity" yaml:"memoryCapacity"`
-	MemoryRequestsFraction string `json:"memoryRequestsFraction" yaml:"memoryRequestsFraction"`
-	MemoryLimitsFraction   string `json:"memoryLimitsFraction" yaml:"memoryLimitsFraction"`
-
-	AllocatedPods int    `json:"allocatedPods" yaml:"allocatedPods"`
-	PodCapacity   int64  `json:"podCapacity" yaml:"podCapacity"`
-	PodFraction   string `json:"podFraction" yaml:"podFraction"`
-
-	Age string `json:"age" yaml:"age"`
+	// Cluster is the kubeconfig context of multi-cluster reports
+	Cluster  string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	NodeName string `json:"nodeName" yaml:"nodeName"`
+	// NoMetrics is set for the nodes missing from the metrics API, e.g. with
+	// an unreachable kubelet, their usage is unknown
+	NoMetrics bool `json:"noMetrics,omitempty" yaml:"noMetrics,omitempty"`
+	// NodeIP is the first InternalIP, falling back to the first ExternalIP and
+	// the hostname, empty for nodes without addresses
+	NodeIP              string  `json:"nodeIP" yaml:"nodeIP"`
+	CPUUsages           int64   `json:"cpuUsages" yaml:"cpuUsages"`
+	CPURequests         int64   `json:"cpuRequests" yaml:"cpuRequests"`
+	CPULimits           int64   `json:"cpuLimits" yaml:"cpuLimits"`
+	CPUCapacity         int64   `json:"cpuCapacity" yaml:"cpuCapacity"`
+	CPURequestsFraction float64 `json:"cpuRequestsFraction" yaml:"cpuRequestsFraction"`
+	CPULimitsFraction   float64 `json:"cpuLimitsFraction" yaml:"cpuLimitsFraction"`
+
+	MemoryUsages           int64   `json:"memoryUsages" yaml:"memoryUsages"`
+	MemoryRequests         int64   `json:"memoryRequests" yaml:"memoryRequests"`
+	MemoryLimits           int64   `json:"memoryLimits" yaml:"memoryLimits"`
+	MemoryCapacity         int64   `json:"memoryCapacity" yaml:"memoryCapacity"`
+	MemoryRequestsFraction float64 `json:"memoryRequestsFraction" yaml:"memoryRequestsFraction"`
+	MemoryLimitsFraction   float64 `json:"memoryLimitsFraction" yaml:"memoryLimitsFraction"`
+
+	AllocatedPods int     `json:"allocatedPods" yaml:"allocatedPods"`
+	PodCapacity   int64   `json:"podCapacity" yaml:"podCapacity"`
+	PodFraction   float64 `json:"podFraction" yaml:"podFraction"`
+
+	QOS QOSBreakdown `json:"qos" yaml:"qos"`
+
+	CreationTimestamp metav1.Time `json:"creationTimestamp" yaml:"creationTimestamp"`
+
+	Roles          []string `json:"roles" yaml:"roles"`
+	KubeletVersion string   `json:"kubeletVersion" yaml:"kubeletVersion"`
+	OSImage        string   `json:"osImage" yaml:"osImage"`
+	Zone           string   `json:"zone" yaml:"zone"`
+	InstanceType   string   `json:"instanceType" yaml:"instanceType"`
+	Taints         int      `json:"taints" yaml:"taints"`
+	TaintEffects   []string `json:"taintEffects" yaml:"taintEffects"`
+	Conditions     []string `json:"conditions" yaml:"conditions"`
+	Schedulable    bool     `json:"schedulable" yaml:"schedulable"`
+	// Status is Ready, NotReady or Unknown, followed by SchedulingDisabled
+	// for cordoned nodes
+	Status string `json:"status" yaml:"status"`
+	Ready  bool   `json:"ready" yaml:"ready"`
+
+	// InternalIPs and ExternalIPs hold both families on dual-stack nodes
+	InternalIPs []string `json:"internalIPs" yaml:"internalIPs"`
+	ExternalIPs []string `json:"externalIPs" yaml:"externalIPs"`
+	Hostname    string   `json:"hostname" yaml:"hostname"`
+
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 }
 
-// NodeResources
-func (k *KubeClient) GetNodeResources(sortBy string, selector labels.Selector) ([]NodeResources, error) {
+// GetNodeResources returns a row per node of the Node API, nodes missing from
+// the metrics API are kept with NoMetrics set
+func (k *KubeClient) GetNodeResources(selector labels.Selector) ([]NodeResources, error) {
 	//resources := make(map[string]map[string]interface{})
 	var resources []NodeResources
-	var nodenames []string
-
-	metrics, err := k.GetNodeMetricsFromMetricsAPI("", selector)
-	if err != nil {
-		return nil, err
-	}
-	//判断是否排序
-	if len(sortBy) > 0 {
-		sort.Sort(metricsutil.NewNodeMetricsSorter(metrics.Items, sortBy))
-	}
-	for _, i := range metrics.Items {
-		nodenames = append(nodenames, i.Name)
-	}
 
 	nodes, err := k.GetNodes("", selector)
 	if err != nil {
 		return nil, err
 	}
+	nodenames := make([]string, 0, len(nodes))
+	for nodename := range nodes {
+		nodenames = append(nodenames, nodename)
+	}
+	sort.Strings(nodenames)
 
 	for _, nodename := range nodenames {
-		//resource := make(map[string]interface{})
-		var resource NodeResources
-		activePodsList, err := k.GetActivePodByNodename(nodes[nodename])
-		if err != nil {
-			return nil, err
-		}
-		NodeMetricsList, err := k.GetNodeMetricsFromMetricsAPI(nodename, selector)
+		resource, err := k.cachedNodeResources(nodes[nodename])
 		if err != nil {
 			return nil, err
 		}
-
-		resource.NodeName = nodename
-		resource.NodeIP = nodes[nodename].Status.Addresses[0].Address
-		resource.Age = time.Since(nodes[nodename].CreationTimestamp.Time).String()
-		noderesource, err := getNodeAllocatedResources(nodes[nodename], activePodsList, NodeMetricsList)
-		if err != nil {
-			log.Printf("Couldn't get allocated resources of %s node: %s\n", nodename, err)
-		}
-		resource.CPUUsages = noderesource.CPUUsages.String()
-		resource.CPURequests = noderesource.CPURequests.String()
-		resource.CPULimits = noderesource.CPULimits.String()
-		resource.CPUCapacity = noderesource.CPUCapacity.String()
-		resource.CPURequestsFraction = ExceedsCompare(float64ToString(noderesource.CPURequestsFraction))
-		resource.CPULimitsFraction = float64ToString(noderesource.CPULimitsFraction)
-
-		resource.MemoryUsages = noderesource.MemoryUsages.String()
-		resource.MemoryRequests = noderesource.MemoryRequests.String()
-		resource.MemoryLimits = noderesource.MemoryLimits.String()
-		resource.MemoryCapacity = noderesource.MemoryCapacity.String()
-		resource.MemoryRequestsFraction = ExceedsCompare(float64ToString(noderesource.MemoryRequestsFraction))
-		resource.MemoryLimitsFraction = float64ToString(noderesource.MemoryLimitsFraction)
-
-		resource.AllocatedPods = noderesource.AllocatedPods
-		resource.PodCapacity = noderesource.PodCapacity
-		resource.PodFraction = ExceedsCompare(float64ToString(noderesource.PodFraction))
 		resources = append(resources, resource)
 	}
 	return resources, err
 }
 
+// nodeResources computes the row of a node from its active pods and metrics
+func (k *KubeClient) nodeResources(node corev1.Node) (NodeResources, error) {
+	var resource NodeResources
+	nodename := node.Name
+	activePodsList, err := k.GetActivePodByNodename(node)
+	if err != nil {
+		return resource, err
+	}
+	NodeMetricsList, err := k.GetNodeMetricsFromMetricsAPI(nodename, labels.Everything())
+	if apierrors.IsNotFound(err) {
+		// the kubelet is unreachable or the node is still joining
+		resource.NoMetrics = true
+		NodeMetricsList, err = &metricsapi.NodeMetricsList{}, nil
+	}
+	if err != nil {
+		return resource, err
+	}
+
+	resource.NodeName = nodename
+	resource.InternalIPs = nodeAddresses(&node, corev1.NodeInternalIP)
+	resource.ExternalIPs = nodeAddresses(&node, corev1.NodeExternalIP)
+	if hostnames := nodeAddresses(&node, corev1.NodeHostName); len(hostnames) > 0 {
+		resource.Hostname = hostnames[0]
+	}
+	resource.NodeIP = firstOf(resource.InternalIPs, resource.ExternalIPs, []string{resource.Hostname})
+	resource.CreationTimestamp = node.CreationTimestamp
+	resource.Roles = nodeRoles(&node)
+	resource.KubeletVersion = node.Status.NodeInfo.KubeletVersion
+	resource.OSImage = node.Status.NodeInfo.OSImage
+	resource.Zone = nodeZone(&node)
+	resource.InstanceType = nodeInstanceType(&node)
+	resource.Taints = len(node.Spec.Taints)
+	resource.TaintEffects = nodeTaintEffects(&node)
+	resource.Conditions = nodePressureConditions(&node)
+	resource.Schedulable = !node.Spec.Unschedulable
+	resource.Status = nodeStatus(&node)
+	resource.Ready = nodeReady(&node)
+	resource.Labels = node.Labels
+	noderesource, err := getNodeAllocatedResources(node, activePodsList, NodeMetricsList)
+	if err != nil {
+		log.Printf("Couldn't get allocated resources of %s node: %s\n", nodename, err)
+	}
+	resource.CPUUsages = noderesource.CPUUsages.MilliValue()
+	resource.CPURequests = noderesource.CPURequests.MilliValue()
+	resource.CPULimits = noderesource.CPULimits.MilliValue()
+	resource.CPUCapacity = noderesource.CPUCapacity.MilliValue()
+	resource.CPURequestsFraction = noderesource.CPURequestsFraction
+	resource.CPULimitsFraction = noderesource.CPULimitsFraction
+
+	resource.MemoryUsages = noderesource.MemoryUsages.Value()
+	resource.MemoryRequests = noderesource.MemoryRequests.Value()
+	resource.MemoryLimits = noderesource.MemoryLimits.Value()
+	resource.MemoryCapacity = noderesource.MemoryCapacity.Value()
+	resource.MemoryRequestsFraction = noderesource.MemoryRequestsFraction
+	resource.MemoryLimitsFraction = noderesource.MemoryLimitsFraction
+
+	resource.AllocatedPods = noderesource.AllocatedPods
+	resource.PodCapacity = noderesource.PodCapacity
+	resource.PodFraction = noderesource.PodFraction
+	resource.QOS, err = podsQOSBreakdown(activePodsList.Items)
+	return resource, err
+}
+
+// PodsResources is the computed resource overview of a single pod. CPU values
+// are in millicores and memory values in bytes.
 type PodsResources struct {
-	Name                 string `json:"name" yaml:"name"`
-	Namespace            string `json:"namespace" yaml:"namespace"`
-	CPUUsages            string `json:"cpuUsages" yaml:"cpuUsages"`
-	CPURequests          string `json:"cpuRequests" yaml:"cpuRequests"`
-	CPULimits            string `json:"cpuLimits" yaml:"cpuLimits"`
-	CPUUsagesFraction    string `json:"cpuUsagesFraction" yaml:"cpuUsagesFraction"`
-	MemoryUsages         string `json:"memoryUsages" yaml:"memoryUsages"`
-	MemoryRequests       string `json:"memoryRequests" yaml:"memoryRequests"`
-	MemoryLimits         string `json:"memoryLimits" yaml:"memoryLimits"`
-	MemoryUsagesFraction string `json:"memoryUsagesFraction" yaml:"memoryUsagesFraction"`
+	// Cluster is the kubeconfig context of multi-cluster reports
+	Cluster   string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+	UID       string `json:"uid" yaml:"uid"`
+	// NoMetrics is set for the pods missing from the metrics API, their usage
+	// is unknown
+	NoMetrics            bool    `json:"noMetrics,omitempty" yaml:"noMetrics,omitempty"`
+	CPUUsages            int64   `json:"cpuUsages" yaml:"cpuUsages"`
+	CPURequests          int64   `json:"cpuRequests" yaml:"cpuRequests"`
+	CPULimits            int64   `json:"cpuLimits" yaml:"cpuLimits"`
+	CPUUsagesFraction    float64 `json:"cpuUsagesFraction" yaml:"cpuUsagesFraction"`
+	MemoryUsages         int64   `json:"memoryUsages" yaml:"memoryUsages"`
+	MemoryRequests       int64   `json:"memoryRequests" yaml:"memoryRequests"`
+	MemoryLimits         int64   `json:"memoryLimits" yaml:"memoryLimits"`
+	MemoryUsagesFraction float64 `json:"memoryUsagesFraction" yaml:"memoryUsagesFraction"`
+
+	NodeName string `json:"nodeName" yaml:"nodeName"`
+	QOSClass string `json:"qosClass" yaml:"qosClass"`
+	Restarts int32  `json:"restarts" yaml:"restarts"`
+	Phase    string `json:"phase" yaml:"phase"`
+	// PendingReason and PendingMessage tell why a Pending pod does not run,
+	// e.g. Unschedulable with the message of the scheduler
+	PendingReason  string `json:"pendingReason,omitempty" yaml:"pendingReason,omitempty"`
+	PendingMessage string `json:"pendingMessage,omitempty" yaml:"pendingMessage,omitempty"`
+	PriorityClass  string `json:"priorityClass" yaml:"priorityClass"`
+	Owner          string `json:"owner" yaml:"owner"`
+
+	// OOMKilled counts the OOM kills among the last and current termination
+	// of the containers, LastTerminated lists the last termination of the
+	// restarted containers
+	OOMKilled      int32    `json:"oomKilled" yaml:"oomKilled"`
+	LastTerminated []string `json:"lastTerminated" yaml:"lastTerminated"`
+	// Warnings and LastWarning summarize the recent Warning events of the pod,
+	// they are only filled by AddPodWarnings
+	Warnings    int32  `json:"warnings" yaml:"warnings"`
+	LastWarning string `json:"lastWarning,omitempty" yaml:"lastWarning,omitempty"`
+
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// NamespaceLabels are the labels of the namespace of the pod, only filled
+	// by AddNamespaceLabels for grouping and kept out of the output
+	NamespaceLabels map[string]string `json:"-" yaml:"-"`
+
+	CreationTimestamp metav1.Time `json:"creationTimestamp" yaml:"creationTimestamp"`
 }
 
-func (k *KubeClient) GetPodResources(podmetrics []metricsapi.PodMetrics, namespace string, sortBy string) ([]PodsResources, error) {
+// GetPodResources returns a row per pod of the Pod API matching the selectors,
+// all namespaces when namespace is empty. Pods missing from the metrics API,
+// e.g. Pending or just started ones, are kept with NoMetrics set.
+func (k *KubeClient) GetPodResources(namespace string, labelSelector labels.Selector, fieldSelector fields.Selector) ([]PodsResources, error) {
 	var resources []PodsResources
 
-	//判断是否排序
-	if len(sortBy) > 0 {
-		allNamespaces := true
-		if len(namespace) > 0 {
-			allNamespaces = false
-		}
-		sort.Sort(metricsutil.NewPodMetricsSorter(podmetrics, allNamespaces, sortBy))
+	pods, err := k.loader.listPods(namespace, metav1.ListOptions{LabelSelector: labelSelector.String(), FieldSelector: fieldSelector.String()})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, nil
+	}
+	// the metrics API only supports metadata fields in field selectors
+	podMetrics, err := k.GetPodMetricsFromMetricsAPI(namespace, labelSelector, fields.Everything())
+	if err != nil {
+		return nil, err
+	}
+	podMetricsByName := make(map[string]metricsapi.PodMetrics)
+	for _, m := range podMetrics.Items {
+		podMetricsByName[m.Namespace+"/"+m.Name] = m
 	}
-	for _, podmetric := range podmetrics {
-		var resource PodsResources
-		pod, err := k.GetPodByPodname(podmetric.Name, podmetric.Namespace)
-		if err != nil {
-			return nil, err
-		}
 
-		resource.Name = podmetric.Name
-		resource.Namespace = podmetric.Namespace
+	for i := range pods.Items {
+		var resource PodsResources
+		pod := &pods.Items[i]
+		podmetric, ok := podMetricsByName[pod.Namespace+"/"+pod.Name]
+		resource.NoMetrics = !ok
+
+		resource.Name = pod.Name
+		resource.Namespace = pod.Namespace
+		resource.UID = string(pod.UID)
+		resource.NodeName = pod.Spec.NodeName
+		resource.QOSClass = string(podQOSClass(pod))
+		resource.Restarts = podRestarts(pod)
+		resource.Phase = string(pod.Status.Phase)
+		resource.PendingReason, resource.PendingMessage = podPendingReason(pod)
+		resource.PriorityClass = pod.Spec.PriorityClassName
+		resource.Owner = podOwner(pod)
+		resource.Labels = pod.Labels
+		resource.OOMKilled = podOOMKilled(pod)
+		resource.LastTerminated = podLastTerminations(pod)
+		resource.CreationTimestamp = pod.CreationTimestamp
 		podresource, err := getPodAllocatedResources(pod, &podmetric)
 		if err != nil {
 			return nil, err
 		}
 
-		resource.CPUUsages = podresource.CPUUsages.String()
-		resource.CPUUsagesFraction = ExceedsCompare(float64ToString(podresource.CPUUsagesFraction))
-		resource.CPURequests = podresource.CPURequests.String()
-		resource.CPULimits = podresource.CPULimits.String()
+		resource.CPUUsages = podresource.CPUUsages.MilliValue()
+		resource.CPUUsagesFraction = podresource.CPUUsagesFraction
+		resource.CPURequests = podresource.CPURequests.MilliValue()
+		resource.CPULimits = podresource.CPULimits.MilliValue()
 
-		resource.MemoryUsages = podresource.MemoryUsages.String()
-		resource.MemoryUsagesFraction = ExceedsCompare(float64ToString(podresource.MemoryUsagesFraction))
-		resource.MemoryRequests = podresource.MemoryRequests.String()
-		resource.MemoryLimits = podresource.MemoryLimits.String()
+		resource.MemoryUsages = podresource.MemoryUsages.Value()
+		resource.MemoryUsagesFraction = podresource.MemoryUsagesFraction
+		resource.MemoryRequests = podresource.MemoryRequests.Value()
+		resource.MemoryLimits = podresource.MemoryLimits.Value()
 		resources = append(resources, resource)
 	}
-
 	return resources, nil
 }
 
+// PodPhaseSelector selects the pods of a phase, the active ones, neither
+// Succeeded nor Failed, when phase is empty
+func PodPhaseSelector(phase string) fields.Selector {
+	if len(phase) > 0 {
+		return fields.OneTermEqualSelector("status.phase", phase)
+	}
+	return fields.AndSelectors(
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)))
+}
+
 // PodMetricses returns all pods' usage metrics
 func (k *KubeClient) PodMetricses() (*metricsV1beta1api.PodMetricsList, error) {
-	podMetricses, err := k.metricsClient.MetricsV1beta1().PodMetricses(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	podMetricses, err := k.loader.listPodMetrics(metav1.NamespaceAll, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -349,16 +504,14 @@ func (k *KubeClient) PodMetricses() (*metricsV1beta1api.PodMetricsList, error) {
 func (k *KubeClient) GetNodeMetricsFromMetricsAPI(resourceName string, selector labels.Selector) (*metricsapi.NodeMetricsList, error) {
 	var err error
 	versionedMetrics := &metricsV1beta1api.NodeMetricsList{}
-	mc := k.metricsClient.MetricsV1beta1()
-	nm := mc.NodeMetricses()
 	if resourceName != "" {
-		m, err := nm.Get(context.TODO(), resourceName, metav1.GetOptions{})
+		m, err := k.loader.getNodeMetrics(resourceName)
 		if err != nil {
 			return nil, err
 		}
 		versionedMetrics.Items = []metricsV1beta1api.NodeMetrics{*m}
 	} else {
-		versionedMetrics, err = nm.List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+		versionedMetrics, err = k.loader.listNodeMetrics(metav1.ListOptions{LabelSelector: selector.String()})
 		if err != nil {
 			return nil, err
 		}
@@ -381,7 +534,7 @@ func (k *KubeClient) GetPodMetricsFromMetricsAPI(namespace string, labelSelector
 	}
 
 	versionedMetrics := &metricsV1beta1api.PodMetricsList{}
-	versionedMetrics, err = k.metricsClient.MetricsV1beta1().PodMetricses(ns).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector.String(), FieldSelector: fieldSelector.String()})
+	versionedMetrics, err = k.loader.listPodMetrics(ns, metav1.ListOptions{LabelSelector: labelSelector.String(), FieldSelector: fieldSelector.String()})
 	if err != nil {
 		return nil, err
 	}
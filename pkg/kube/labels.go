@@ -0,0 +1,84 @@
+package kube
+
+import (
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// listNamespaces returns the namespaces, none when the user may not list them
+func (k *KubeClient) listNamespaces() ([]corev1.Namespace, error) {
+	namespaces, err := k.loader.listNamespaces(metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		log.Printf("Couldn't list namespaces: %s\n", err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return namespaces.Items, nil
+}
+
+// AddNamespaceLabels sets the namespace labels of the pods, GroupPods falls
+// back to them for labels such as team that are often set namespace-wide
+func (k *KubeClient) AddNamespaceLabels(rows []PodsResources) error {
+	namespaces, err := k.listNamespaces()
+	if err != nil {
+		return err
+	}
+	labels := map[string]map[string]string{}
+	for _, ns := range namespaces {
+		labels[ns.Name] = ns.Labels
+	}
+	for i := range rows {
+		rows[i].NamespaceLabels = labels[rows[i].Namespace]
+	}
+	return nil
+}
+
+// PodGroup aggregates the pods sharing the same value of a label
+type PodGroup struct {
+	// Cluster is the kubeconfig context of multi-cluster reports, see NodeGroup
+	Cluster string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Label   string `json:"label" yaml:"label"`
+	Value   string `json:"value" yaml:"value"`
+	// Namespaces is the number of namespaces the group spans
+	Namespaces int `json:"namespaces" yaml:"namespaces"`
+	PodSummary
+}
+
+// GroupPods collapses the pod rows by cluster and value of label, taken from
+// the pod or else its namespace. Pods without the label are gathered in a
+// <none> group. Groups are ordered by cluster and
+// value.
+func GroupPods(rows []PodsResources, label string) []PodGroup {
+	members := map[groupKey][]PodsResources{}
+	namespaces := map[groupKey]map[string]bool{}
+	var keys []groupKey
+	for _, r := range rows {
+		value, ok := r.Labels[label]
+		if !ok {
+			value, ok = r.NamespaceLabels[label]
+		}
+		if !ok {
+			value = none
+		}
+		key := groupKey{cluster: r.Cluster, value: value}
+		if _, ok := members[key]; !ok {
+			keys = append(keys, key)
+			namespaces[key] = map[string]bool{}
+		}
+		members[key] = append(members[key], r)
+		namespaces[key][r.Namespace] = true
+	}
+	sortGroupKeys(keys)
+
+	groups := make([]PodGroup, 0, len(keys))
+	for _, key := range keys {
+		groups = append(groups, PodGroup{Cluster: key.cluster, Label: label, Value: key.value,
+			Namespaces: len(namespaces[key]), PodSummary: SummarizePods(members[key])})
+	}
+	return groups
+}
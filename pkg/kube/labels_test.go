@@ -0,0 +1,38 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGroupPods(t *testing.T) {
+	k := NewSnapshotClient(&Snapshot{Namespaces: []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"team": "sales"}}},
+	}})
+	rows := []PodsResources{
+		{Namespace: "shop", Name: "web", CPURequests: 100},
+		// the label of the pod wins over the one of its namespace
+		{Namespace: "shop", Name: "api", CPURequests: 200, Labels: map[string]string{"team": "core"}},
+		{Namespace: "tools", Name: "debug", CPURequests: 50},
+		{Cluster: "prod-eu", Namespace: "shop", Name: "web", CPURequests: 100, Labels: map[string]string{"team": "core"}},
+	}
+	if err := k.AddNamespaceLabels(rows); err != nil {
+		t.Fatal(err)
+	}
+	// the namespace labels are kept apart from the labels of the pods
+	if rows[0].Labels != nil {
+		t.Errorf("AddNamespaceLabels() set the pod labels to %v", rows[0].Labels)
+	}
+
+	got := map[string]int64{}
+	for _, g := range GroupPods(rows, "team") {
+		got[g.Cluster+"/"+g.Value] = g.CPURequests
+	}
+	want := map[string]int64{"/core": 200, "/sales": 100, "/<none>": 50, "prod-eu/core": 100}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupPods(team) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package kube
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	metricsV1beta1api "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// loader fetches the objects the reports are computed from. It is backed by
+// the API server, or by a snapshot file for offline analysis.
+type loader interface {
+	getNode(name string) (*corev1.Node, error)
+	listNodes(opts metav1.ListOptions) (*corev1.NodeList, error)
+	getPod(namespace, name string) (*corev1.Pod, error)
+	listPods(namespace string, opts metav1.ListOptions) (*corev1.PodList, error)
+	listEvents(namespace string, opts metav1.ListOptions) (*corev1.EventList, error)
+	listNamespaces(opts metav1.ListOptions) (*corev1.NamespaceList, error)
+	getNodeMetrics(name string) (*metricsV1beta1api.NodeMetrics, error)
+	listNodeMetrics(opts metav1.ListOptions) (*metricsV1beta1api.NodeMetricsList, error)
+	listPodMetrics(namespace string, opts metav1.ListOptions) (*metricsV1beta1api.PodMetricsList, error)
+	// now returns the time the objects are read at
+	now() time.Time
+}
+
+// apiLoader reads from the API server and the metrics API
+type apiLoader struct {
+	apiClient     kubernetes.Interface
+	metricsClient metrics.Interface
+}
+
+func (l *apiLoader) getNode(name string) (*corev1.Node, error) {
+	return l.apiClient.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (l *apiLoader) listNodes(opts metav1.ListOptions) (*corev1.NodeList, error) {
+	return l.apiClient.CoreV1().Nodes().List(context.TODO(), opts)
+}
+
+func (l *apiLoader) getPod(namespace, name string) (*corev1.Pod, error) {
+	return l.apiClient.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (l *apiLoader) listPods(namespace string, opts metav1.ListOptions) (*corev1.PodList, error) {
+	return l.apiClient.CoreV1().Pods(namespace).List(context.TODO(), opts)
+}
+
+func (l *apiLoader) listEvents(namespace string, opts metav1.ListOptions) (*corev1.EventList, error) {
+	return l.apiClient.CoreV1().Events(namespace).List(context.TODO(), opts)
+}
+
+func (l *apiLoader) listNamespaces(opts metav1.ListOptions) (*corev1.NamespaceList, error) {
+	return l.apiClient.CoreV1().Namespaces().List(context.TODO(), opts)
+}
+
+func (l *apiLoader) getNodeMetrics(name string) (*metricsV1beta1api.NodeMetrics, error) {
+	return l.metricsClient.MetricsV1beta1().NodeMetricses().Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (l *apiLoader) listNodeMetrics(opts metav1.ListOptions) (*metricsV1beta1api.NodeMetricsList, error) {
+	return l.metricsClient.MetricsV1beta1().NodeMetricses().List(context.TODO(), opts)
+}
+
+func (l *apiLoader) listPodMetrics(namespace string, opts metav1.ListOptions) (*metricsV1beta1api.PodMetricsList, error) {
+	return l.metricsClient.MetricsV1beta1().PodMetricses(namespace).List(context.TODO(), opts)
+}
+
+func (l *apiLoader) now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,75 @@
+package kube
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics"
+)
+
+// NamespaceResources sums the active pods of a namespace. CPU values are in
+// millicores and memory values in bytes.
+type NamespaceResources struct {
+	Namespace      string `json:"namespace" yaml:"namespace"`
+	Pods           int    `json:"pods" yaml:"pods"`
+	CPUUsages      int64  `json:"cpuUsages" yaml:"cpuUsages"`
+	CPURequests    int64  `json:"cpuRequests" yaml:"cpuRequests"`
+	CPULimits      int64  `json:"cpuLimits" yaml:"cpuLimits"`
+	MemoryUsages   int64  `json:"memoryUsages" yaml:"memoryUsages"`
+	MemoryRequests int64  `json:"memoryRequests" yaml:"memoryRequests"`
+	MemoryLimits   int64  `json:"memoryLimits" yaml:"memoryLimits"`
+
+	QOS QOSBreakdown `json:"qos" yaml:"qos"`
+}
+
+// GetNamespaceResources sums the active pods per namespace, all namespaces
+// when namespace is empty. Namespaces are ordered by name.
+func (k *KubeClient) GetNamespaceResources(namespace string) ([]NamespaceResources, error) {
+	pods, err := k.GetActivePods(namespace)
+	if err != nil {
+		return nil, err
+	}
+	podMetrics, err := k.GetPodMetricsFromMetricsAPI(namespace, labels.Everything(), fields.Everything())
+	if err != nil {
+		return nil, err
+	}
+	podMetricsByName := make(map[string]metricsapi.PodMetrics)
+	for _, m := range podMetrics.Items {
+		podMetricsByName[m.Namespace+"/"+m.Name] = m
+	}
+
+	byNamespace := map[string]*NamespaceResources{}
+	var names []string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		r, ok := byNamespace[pod.Namespace]
+		if !ok {
+			r = &NamespaceResources{Namespace: pod.Namespace}
+			byNamespace[pod.Namespace] = r
+			names = append(names, pod.Namespace)
+		}
+		podmetric := podMetricsByName[pod.Namespace+"/"+pod.Name]
+		podresource, err := getPodAllocatedResources(pod, &podmetric)
+		if err != nil {
+			return nil, err
+		}
+		r.Pods++
+		r.CPUUsages += podresource.CPUUsages.MilliValue()
+		r.CPURequests += podresource.CPURequests.MilliValue()
+		r.CPULimits += podresource.CPULimits.MilliValue()
+		r.MemoryUsages += podresource.MemoryUsages.Value()
+		r.MemoryRequests += podresource.MemoryRequests.Value()
+		r.MemoryLimits += podresource.MemoryLimits.Value()
+		if err := r.QOS.addPod(pod); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(names)
+
+	resources := make([]NamespaceResources, 0, len(names))
+	for _, name := range names {
+		resources = append(resources, *byNamespace[name])
+	}
+	return resources, nil
+}
@@ -0,0 +1,84 @@
+package kube
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// QOSResources sums the pods of a QoS class. CPU values are in millicores and
+// memory values in bytes.
+type QOSResources struct {
+	Pods           int   `json:"pods" yaml:"pods"`
+	CPURequests    int64 `json:"cpuRequests" yaml:"cpuRequests"`
+	CPULimits      int64 `json:"cpuLimits" yaml:"cpuLimits"`
+	MemoryRequests int64 `json:"memoryRequests" yaml:"memoryRequests"`
+	MemoryLimits   int64 `json:"memoryLimits" yaml:"memoryLimits"`
+}
+
+func (r *QOSResources) add(o QOSResources) {
+	r.Pods += o.Pods
+	r.CPURequests += o.CPURequests
+	r.CPULimits += o.CPULimits
+	r.MemoryRequests += o.MemoryRequests
+	r.MemoryLimits += o.MemoryLimits
+}
+
+// QOSBreakdown splits pods by QoS class
+type QOSBreakdown struct {
+	Guaranteed QOSResources `json:"guaranteed" yaml:"guaranteed"`
+	Burstable  QOSResources `json:"burstable" yaml:"burstable"`
+	BestEffort QOSResources `json:"bestEffort" yaml:"bestEffort"`
+	// GuaranteedFraction is the share of the pods in the Guaranteed class
+	GuaranteedFraction float64 `json:"guaranteedFraction" yaml:"guaranteedFraction"`
+}
+
+// class returns the totals of a QoS class
+func (b *QOSBreakdown) class(c corev1.PodQOSClass) *QOSResources {
+	switch c {
+	case corev1.PodQOSGuaranteed:
+		return &b.Guaranteed
+	case corev1.PodQOSBurstable:
+		return &b.Burstable
+	}
+	return &b.BestEffort
+}
+
+// Pods returns the number of pods across the classes
+func (b QOSBreakdown) Pods() int {
+	return b.Guaranteed.Pods + b.Burstable.Pods + b.BestEffort.Pods
+}
+
+// addPod counts the pod and its requests and limits in its QoS class
+func (b *QOSBreakdown) addPod(pod *corev1.Pod) error {
+	reqs, limits, err := PodRequestsAndLimits(pod)
+	if err != nil {
+		return err
+	}
+	b.class(podQOSClass(pod)).add(QOSResources{
+		Pods:           1,
+		CPURequests:    reqs.Cpu().MilliValue(),
+		CPULimits:      limits.Cpu().MilliValue(),
+		MemoryRequests: reqs.Memory().Value(),
+		MemoryLimits:   limits.Memory().Value(),
+	})
+	b.GuaranteedFraction = calcPercentage(int64(b.Guaranteed.Pods), int64(b.Pods()))
+	return nil
+}
+
+// add merges another breakdown, e.g. to sum nodes
+func (b *QOSBreakdown) add(o QOSBreakdown) {
+	b.Guaranteed.add(o.Guaranteed)
+	b.Burstable.add(o.Burstable)
+	b.BestEffort.add(o.BestEffort)
+	b.GuaranteedFraction = calcPercentage(int64(b.Guaranteed.Pods), int64(b.Pods()))
+}
+
+// podsQOSBreakdown splits a pod list by QoS class
+func podsQOSBreakdown(pods []corev1.Pod) (QOSBreakdown, error) {
+	var b QOSBreakdown
+	for i := range pods {
+		if err := b.addPod(&pods[i]); err != nil {
+			return b, err
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,136 @@
+package kube
+
+import (
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics"
+)
+
+// EvictionCandidate is a pod the kubelet may evict under memory pressure.
+// Memory values are in bytes.
+type EvictionCandidate struct {
+	Name                string `json:"name" yaml:"name"`
+	Namespace           string `json:"namespace" yaml:"namespace"`
+	QOSClass            string `json:"qosClass" yaml:"qosClass"`
+	Priority            int32  `json:"priority" yaml:"priority"`
+	MemoryUsages        int64  `json:"memoryUsages" yaml:"memoryUsages"`
+	MemoryRequests      int64  `json:"memoryRequests" yaml:"memoryRequests"`
+	MemoryLimits        int64  `json:"memoryLimits" yaml:"memoryLimits"`
+	MemoryAboveRequests int64  `json:"memoryAboveRequests" yaml:"memoryAboveRequests"`
+}
+
+// NodeRisk ranks a node by how likely it is to hit memory pressure. Score is
+// the memory usage fraction of the allocatable, scaled up by the memory limits
+// overcommit when the limits exceed the allocatable.
+type NodeRisk struct {
+	NodeName             string              `json:"nodeName" yaml:"nodeName"`
+	MemoryUsages         int64               `json:"memoryUsages" yaml:"memoryUsages"`
+	MemoryLimits         int64               `json:"memoryLimits" yaml:"memoryLimits"`
+	MemoryCapacity       int64               `json:"memoryCapacity" yaml:"memoryCapacity"`
+	MemoryUsagesFraction float64             `json:"memoryUsagesFraction" yaml:"memoryUsagesFraction"`
+	MemoryLimitsFraction float64             `json:"memoryLimitsFraction" yaml:"memoryLimitsFraction"`
+	Score                float64             `json:"score" yaml:"score"`
+	Pods                 []EvictionCandidate `json:"pods" yaml:"pods"`
+}
+
+// qosEvictionRank orders the QoS classes the way the kubelet picks victims
+var qosEvictionRank = map[corev1.PodQOSClass]int{
+	corev1.PodQOSBestEffort: 0,
+	corev1.PodQOSBurstable:  1,
+	corev1.PodQOSGuaranteed: 2,
+}
+
+// GetNodeRisks returns the nodes ranked by memory risk, each listing its
+// BestEffort and Burstable pods in eviction order: QoS class first, then the
+// lowest priority, then the memory used above the requests
+func (k *KubeClient) GetNodeRisks(selector labels.Selector) ([]NodeRisk, error) {
+	nodes, err := k.GetNodes("", selector)
+	if err != nil {
+		return nil, err
+	}
+	nodeMetrics, err := k.GetNodeMetricsFromMetricsAPI("", selector)
+	if err != nil {
+		return nil, err
+	}
+	podMetrics, err := k.GetPodMetricsFromMetricsAPI("", labels.Everything(), fields.Everything())
+	if err != nil {
+		return nil, err
+	}
+	podMetricsByName := make(map[string]metricsapi.PodMetrics)
+	for _, m := range podMetrics.Items {
+		podMetricsByName[m.Namespace+"/"+m.Name] = m
+	}
+
+	var risks []NodeRisk
+	for _, node := range nodes {
+		activePodsList, err := k.GetActivePodByNodename(node)
+		if err != nil {
+			return nil, err
+		}
+		noderesource, err := getNodeAllocatedResources(node, activePodsList, nodeMetrics)
+		if err != nil {
+			return nil, err
+		}
+		risk := NodeRisk{
+			NodeName:             node.Name,
+			MemoryUsages:         noderesource.MemoryUsages.Value(),
+			MemoryLimits:         noderesource.MemoryLimits.Value(),
+			MemoryCapacity:       noderesource.MemoryCapacity.Value(),
+			MemoryUsagesFraction: noderesource.MemoryUsages.calcPercentage(noderesource.MemoryCapacity.Quantity),
+			MemoryLimitsFraction: noderesource.MemoryLimitsFraction,
+			Pods:                 []EvictionCandidate{},
+		}
+		risk.Score = risk.MemoryUsagesFraction
+		if risk.MemoryLimitsFraction > 100 {
+			risk.Score = math.Round(risk.MemoryUsagesFraction*risk.MemoryLimitsFraction) / 100
+		}
+
+		for i := range activePodsList.Items {
+			pod := &activePodsList.Items[i]
+			qosClass := podQOSClass(pod)
+			if qosClass == corev1.PodQOSGuaranteed {
+				continue
+			}
+			podmetric := podMetricsByName[pod.Namespace+"/"+pod.Name]
+			podresource, err := getPodAllocatedResources(pod, &podmetric)
+			if err != nil {
+				return nil, err
+			}
+			candidate := EvictionCandidate{
+				Name:           pod.Name,
+				Namespace:      pod.Namespace,
+				QOSClass:       string(qosClass),
+				MemoryUsages:   podresource.MemoryUsages.Value(),
+				MemoryRequests: podresource.MemoryRequests.Value(),
+				MemoryLimits:   podresource.MemoryLimits.Value(),
+			}
+			if pod.Spec.Priority != nil {
+				candidate.Priority = *pod.Spec.Priority
+			}
+			candidate.MemoryAboveRequests = candidate.MemoryUsages - candidate.MemoryRequests
+			risk.Pods = append(risk.Pods, candidate)
+		}
+		sort.SliceStable(risk.Pods, func(i, j int) bool {
+			a, b := risk.Pods[i], risk.Pods[j]
+			if ra, rb := qosEvictionRank[corev1.PodQOSClass(a.QOSClass)], qosEvictionRank[corev1.PodQOSClass(b.QOSClass)]; ra != rb {
+				return ra < rb
+			}
+			if a.Priority != b.Priority {
+				return a.Priority < b.Priority
+			}
+			return a.MemoryAboveRequests > b.MemoryAboveRequests
+		})
+		risks = append(risks, risk)
+	}
+	sort.SliceStable(risks, func(i, j int) bool {
+		if risks[i].Score != risks[j].Score {
+			return risks[i].Score > risks[j].Score
+		}
+		return risks[i].NodeName < risks[j].NodeName
+	})
+	return risks, nil
+}
@@ -0,0 +1,148 @@
+package kube
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// nodeReady reports whether the kubelet posts a Ready condition
+func nodeReady(node *v1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// untoleratedTaint returns the first taint keeping new pods away from the node
+// that the tolerations do not tolerate, PreferNoSchedule taints are ignored
+func untoleratedTaint(node *v1.Node, tolerations []v1.Toleration) *v1.Taint {
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return taint
+		}
+	}
+	return nil
+}
+
+// matchNodeSelector checks the nodeSelector of the pod spec
+func matchNodeSelector(node *v1.Node, spec *v1.PodSpec) bool {
+	return labels.SelectorFromSet(spec.NodeSelector).Matches(labels.Set(node.Labels))
+}
+
+// matchNodeAffinity checks the required node affinity of the pod spec, the
+// terms are ORed and the expressions of a term ANDed
+func matchNodeAffinity(node *v1.Node, spec *v1.PodSpec) (bool, error) {
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil ||
+		spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true, nil
+	}
+	terms := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		ok, err := matchNodeSelectorTerm(node, term)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func matchNodeSelectorTerm(node *v1.Node, term v1.NodeSelectorTerm) (bool, error) {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false, nil
+	}
+	selector := labels.NewSelector()
+	for _, expr := range term.MatchExpressions {
+		op, err := selectionOperator(expr.Operator)
+		if err != nil {
+			return false, err
+		}
+		req, err := labels.NewRequirement(expr.Key, op, expr.Values)
+		if err != nil {
+			return false, err
+		}
+		selector = selector.Add(*req)
+	}
+	if !selector.Matches(labels.Set(node.Labels)) {
+		return false, nil
+	}
+	nodeFields := fields.Set{"metadata.name": node.Name}
+	for _, expr := range term.MatchFields {
+		if expr.Key != "metadata.name" || len(expr.Values) != 1 {
+			return false, fmt.Errorf("unsupported node field selector %s", expr.Key)
+		}
+		matches := fields.OneTermEqualSelector(expr.Key, expr.Values[0]).Matches(nodeFields)
+		switch expr.Operator {
+		case v1.NodeSelectorOpIn:
+			if !matches {
+				return false, nil
+			}
+		case v1.NodeSelectorOpNotIn:
+			if matches {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("unsupported node field selector operator %s", expr.Operator)
+		}
+	}
+	return true, nil
+}
+
+func selectionOperator(op v1.NodeSelectorOperator) (selection.Operator, error) {
+	switch op {
+	case v1.NodeSelectorOpIn:
+		return selection.In, nil
+	case v1.NodeSelectorOpNotIn:
+		return selection.NotIn, nil
+	case v1.NodeSelectorOpExists:
+		return selection.Exists, nil
+	case v1.NodeSelectorOpDoesNotExist:
+		return selection.DoesNotExist, nil
+	case v1.NodeSelectorOpGt:
+		return selection.GreaterThan, nil
+	case v1.NodeSelectorOpLt:
+		return selection.LessThan, nil
+	}
+	return "", fmt.Errorf("unknown node selector operator %s", op)
+}
+
+// unschedulableReason explains why new pods with the given spec can not land
+// on the node regardless of its free resources, it is empty when they can
+func unschedulableReason(node *v1.Node, spec *v1.PodSpec) (string, error) {
+	if node.Spec.Unschedulable {
+		return "SchedulingDisabled", nil
+	}
+	if !nodeReady(node) {
+		return "NotReady", nil
+	}
+	if taint := untoleratedTaint(node, spec.Tolerations); taint != nil {
+		return fmt.Sprintf("Taint %s", taint.ToString()), nil
+	}
+	if !matchNodeSelector(node, spec) {
+		return "NodeSelectorMismatch", nil
+	}
+	ok, err := matchNodeAffinity(node, spec)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "NodeAffinityMismatch", nil
+	}
+	return "", nil
+}
@@ -0,0 +1,274 @@
+package kube
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metricsV1beta1api "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// Snapshot captures everything the reports are computed from, so they can run
+// offline against a file
+type Snapshot struct {
+	CreationTimestamp metav1.Time                     `json:"creationTimestamp"`
+	Nodes             []corev1.Node                   `json:"nodes"`
+	Pods              []corev1.Pod                    `json:"pods"`
+	Events            []corev1.Event                  `json:"events"`
+	Namespaces        []corev1.Namespace              `json:"namespaces,omitempty"`
+	NodeMetrics       []metricsV1beta1api.NodeMetrics `json:"nodeMetrics"`
+	PodMetrics        []metricsV1beta1api.PodMetrics  `json:"podMetrics"`
+}
+
+// SaveSnapshot captures the nodes, the active pods, their Warning events, the
+// namespaces and the node and pod metrics
+func (k *KubeClient) SaveSnapshot() (*Snapshot, error) {
+	s := &Snapshot{CreationTimestamp: metav1.Now()}
+	nodes, err := k.loader.listNodes(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	s.Nodes = nodes.Items
+	pods, err := k.GetActivePods(corev1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	s.Pods = pods.Items
+	fieldSelector := fields.OneTermEqualSelector("type", corev1.EventTypeWarning)
+	events, err := k.loader.listEvents(corev1.NamespaceAll, metav1.ListOptions{FieldSelector: fieldSelector.String()})
+	if err != nil {
+		return nil, err
+	}
+	s.Events = events.Items
+	namespaces, err := k.listNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	s.Namespaces = namespaces
+	nodeMetrics, err := k.loader.listNodeMetrics(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	s.NodeMetrics = nodeMetrics.Items
+	podMetrics, err := k.loader.listPodMetrics(corev1.NamespaceAll, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	s.PodMetrics = podMetrics.Items
+	return s, nil
+}
+
+// Write encodes the snapshot as JSON
+func (s *Snapshot) Write(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// LoadSnapshot reads a snapshot written by Snapshot.Write
+func LoadSnapshot(filename string) (*Snapshot, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse snapshot %s", filename)
+	}
+	return s, nil
+}
+
+// snapshotLoader serves the objects of a snapshot, applying the label and
+// field selectors the way the API server does
+type snapshotLoader struct {
+	snapshot *Snapshot
+}
+
+// parseListOptions parses the selectors of a list request
+func parseListOptions(opts metav1.ListOptions) (labels.Selector, fields.Selector, error) {
+	labelSelector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, nil, err
+	}
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, nil, err
+	}
+	return labelSelector, fieldSelector, nil
+}
+
+func inNamespace(namespace string, meta metav1.ObjectMeta) bool {
+	return namespace == corev1.NamespaceAll || namespace == meta.Namespace
+}
+
+func objectMetaFields(meta metav1.ObjectMeta) fields.Set {
+	return fields.Set{"metadata.name": meta.Name, "metadata.namespace": meta.Namespace}
+}
+
+// podFields are the pod fields the API server supports in field selectors
+func podFields(pod *corev1.Pod) fields.Set {
+	set := objectMetaFields(pod.ObjectMeta)
+	set["spec.nodeName"] = pod.Spec.NodeName
+	set["spec.restartPolicy"] = string(pod.Spec.RestartPolicy)
+	set["spec.schedulerName"] = pod.Spec.SchedulerName
+	set["spec.serviceAccountName"] = pod.Spec.ServiceAccountName
+	set["status.phase"] = string(pod.Status.Phase)
+	set["status.podIP"] = pod.Status.PodIP
+	set["status.nominatedNodeName"] = pod.Status.NominatedNodeName
+	return set
+}
+
+// eventFields are the event fields the API server supports in field selectors
+func eventFields(e *corev1.Event) fields.Set {
+	set := objectMetaFields(e.ObjectMeta)
+	set["involvedObject.kind"] = e.InvolvedObject.Kind
+	set["involvedObject.namespace"] = e.InvolvedObject.Namespace
+	set["involvedObject.name"] = e.InvolvedObject.Name
+	set["involvedObject.uid"] = string(e.InvolvedObject.UID)
+	set["reason"] = e.Reason
+	set["source"] = e.Source.Component
+	set["type"] = e.Type
+	return set
+}
+
+func (l *snapshotLoader) getNode(name string) (*corev1.Node, error) {
+	for i := range l.snapshot.Nodes {
+		if l.snapshot.Nodes[i].Name == name {
+			return l.snapshot.Nodes[i].DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, name)
+}
+
+func (l *snapshotLoader) listNodes(opts metav1.ListOptions) (*corev1.NodeList, error) {
+	labelSelector, fieldSelector, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.NodeList{}
+	for _, node := range l.snapshot.Nodes {
+		if labelSelector.Matches(labels.Set(node.Labels)) && fieldSelector.Matches(objectMetaFields(node.ObjectMeta)) {
+			list.Items = append(list.Items, *node.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (l *snapshotLoader) getPod(namespace, name string) (*corev1.Pod, error) {
+	for i := range l.snapshot.Pods {
+		if pod := &l.snapshot.Pods[i]; pod.Namespace == namespace && pod.Name == name {
+			return pod.DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, name)
+}
+
+func (l *snapshotLoader) listPods(namespace string, opts metav1.ListOptions) (*corev1.PodList, error) {
+	labelSelector, fieldSelector, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.PodList{}
+	for i := range l.snapshot.Pods {
+		pod := &l.snapshot.Pods[i]
+		if inNamespace(namespace, pod.ObjectMeta) && labelSelector.Matches(labels.Set(pod.Labels)) && fieldSelector.Matches(podFields(pod)) {
+			list.Items = append(list.Items, *pod.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (l *snapshotLoader) listEvents(namespace string, opts metav1.ListOptions) (*corev1.EventList, error) {
+	labelSelector, fieldSelector, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.EventList{}
+	for i := range l.snapshot.Events {
+		e := &l.snapshot.Events[i]
+		if inNamespace(namespace, e.ObjectMeta) && labelSelector.Matches(labels.Set(e.Labels)) && fieldSelector.Matches(eventFields(e)) {
+			list.Items = append(list.Items, *e.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (l *snapshotLoader) listNamespaces(opts metav1.ListOptions) (*corev1.NamespaceList, error) {
+	labelSelector, fieldSelector, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.NamespaceList{}
+	for i := range l.snapshot.Namespaces {
+		ns := &l.snapshot.Namespaces[i]
+		if labelSelector.Matches(labels.Set(ns.Labels)) && fieldSelector.Matches(objectMetaFields(ns.ObjectMeta)) {
+			list.Items = append(list.Items, *ns.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (l *snapshotLoader) getNodeMetrics(name string) (*metricsV1beta1api.NodeMetrics, error) {
+	for i := range l.snapshot.NodeMetrics {
+		if l.snapshot.NodeMetrics[i].Name == name {
+			return l.snapshot.NodeMetrics[i].DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: "nodes"}, name)
+}
+
+// listNodeMetrics matches the label selector against the labels of the node,
+// as the metrics server does
+func (l *snapshotLoader) listNodeMetrics(opts metav1.ListOptions) (*metricsV1beta1api.NodeMetricsList, error) {
+	labelSelector, fieldSelector, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	nodeLabels := make(map[string]map[string]string, len(l.snapshot.Nodes))
+	for _, node := range l.snapshot.Nodes {
+		nodeLabels[node.Name] = node.Labels
+	}
+	list := &metricsV1beta1api.NodeMetricsList{}
+	for _, m := range l.snapshot.NodeMetrics {
+		set, ok := nodeLabels[m.Name]
+		if !ok {
+			set = m.Labels
+		}
+		if labelSelector.Matches(labels.Set(set)) && fieldSelector.Matches(objectMetaFields(m.ObjectMeta)) {
+			list.Items = append(list.Items, *m.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (l *snapshotLoader) listPodMetrics(namespace string, opts metav1.ListOptions) (*metricsV1beta1api.PodMetricsList, error) {
+	labelSelector, fieldSelector, err := parseListOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	list := &metricsV1beta1api.PodMetricsList{}
+	for i := range l.snapshot.PodMetrics {
+		m := &l.snapshot.PodMetrics[i]
+		if inNamespace(namespace, m.ObjectMeta) && labelSelector.Matches(labels.Set(m.Labels)) && fieldSelector.Matches(objectMetaFields(m.ObjectMeta)) {
+			list.Items = append(list.Items, *m.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+// now returns the capture time of the snapshot, the current time for
+// snapshots without one
+func (l *snapshotLoader) now() time.Time {
+	if l.snapshot.CreationTimestamp.IsZero() {
+		return time.Now()
+	}
+	return l.snapshot.CreationTimestamp.Time
+}
@@ -0,0 +1,121 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	metricsV1beta1api "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func testSnapshot() *Snapshot {
+	n1, n2 := testNode("n1", "4", "8Gi", map[string]string{"pool": "a"}), testNode("n2", "4", "8Gi", nil)
+	web, api, job := testPod("web", "n1", "100m", "128Mi"), testPod("api", "n2", "100m", "128Mi"), testPod("job", "n1", "100m", "128Mi")
+	job.Status.Phase = corev1.PodSucceeded
+	dns := testPod("dns", "n1", "100m", "128Mi")
+	dns.Namespace = "kube-system"
+	event := func(name, eventType string) corev1.Event {
+		return corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name + "." + eventType, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: name},
+			Type:           eventType,
+		}
+	}
+	return &Snapshot{
+		CreationTimestamp: metav1.NewTime(time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)),
+		Nodes:             []corev1.Node{*n1, *n2},
+		Pods:              []corev1.Pod{*web, *api, *job, *dns},
+		Events:            []corev1.Event{event("web", corev1.EventTypeWarning), event("web", corev1.EventTypeNormal), event("api", corev1.EventTypeWarning)},
+		NodeMetrics:       []metricsV1beta1api.NodeMetrics{*testNodeMetrics("n1", "1", "1Gi"), *testNodeMetrics("n2", "1", "1Gi")},
+	}
+}
+
+func names[T any](items []T, name func(*T) string) []string {
+	var list []string
+	for i := range items {
+		list = append(list, name(&items[i]))
+	}
+	return list
+}
+
+func podListNames(list *corev1.PodList) []string {
+	return names(list.Items, func(p *corev1.Pod) string { return p.Namespace + "/" + p.Name })
+}
+
+func TestSnapshotListPods(t *testing.T) {
+	k := NewSnapshotClient(testSnapshot())
+	tests := []struct {
+		namespace string
+		selector  string
+		want      []string
+	}{
+		{"", "", []string{"default/web", "default/api", "default/job", "kube-system/dns"}},
+		{"default", "", []string{"default/web", "default/api", "default/job"}},
+		{"", PodPhaseSelector("").String(), []string{"default/web", "default/api", "kube-system/dns"}},
+		{"", PodPhaseSelector("Succeeded").String(), []string{"default/job"}},
+		{"", "spec.nodeName=n1", []string{"default/web", "default/job", "kube-system/dns"}},
+		{"", "spec.nodeName=n1,status.phase!=Succeeded", []string{"default/web", "kube-system/dns"}},
+		{"", "metadata.name=api", []string{"default/api"}},
+	}
+	for _, tt := range tests {
+		list, err := k.loader.listPods(tt.namespace, metav1.ListOptions{FieldSelector: tt.selector})
+		if err != nil {
+			t.Errorf("listPods(%q, %q) failed: %v", tt.namespace, tt.selector, err)
+			continue
+		}
+		if got := podListNames(list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("listPods(%q, %q) = %v, want %v", tt.namespace, tt.selector, got, tt.want)
+		}
+	}
+
+	active, err := k.GetActivePodByNodename(*testNode("n1", "4", "8Gi", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := podListNames(active), []string{"default/web", "kube-system/dns"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetActivePodByNodename(n1) = %v, want %v", got, want)
+	}
+	if _, err := k.loader.listPods("", metav1.ListOptions{FieldSelector: "spec.nodeName"}); err == nil {
+		t.Errorf("listPods(%q) succeeded, want an error", "spec.nodeName")
+	}
+}
+
+func TestSnapshotListEvents(t *testing.T) {
+	k := NewSnapshotClient(testSnapshot())
+	selector := "type=Warning,involvedObject.kind=Pod,involvedObject.name=web"
+	list, err := k.loader.listEvents("default", metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := names(list.Items, func(e *corev1.Event) string { return e.Name })
+	if want := []string{"web.Warning"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listEvents(%q) = %v, want %v", selector, got, want)
+	}
+}
+
+func TestSnapshotListNodeMetrics(t *testing.T) {
+	k := NewSnapshotClient(testSnapshot())
+	// the metrics carry no labels, the selector matches the labels of the node
+	list, err := k.loader.listNodeMetrics(metav1.ListOptions{LabelSelector: labels.SelectorFromSet(labels.Set{"pool": "a"}).String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := names(list.Items, func(m *metricsV1beta1api.NodeMetrics) string { return m.Name })
+	if want := []string{"n1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listNodeMetrics(pool=a) = %v, want %v", got, want)
+	}
+}
+
+func TestSnapshotNow(t *testing.T) {
+	s := testSnapshot()
+	if got := NewSnapshotClient(s).Now(); !got.Equal(s.CreationTimestamp.Time) {
+		t.Errorf("Now() = %v, want the capture time %v", got, s.CreationTimestamp)
+	}
+	created := metav1.NewTime(s.CreationTimestamp.Add(-48 * time.Hour))
+	if got := Age(created, NewSnapshotClient(s).Now()); got != "2d" {
+		t.Errorf("Age() = %q, want %q", got, "2d")
+	}
+}
@@ -0,0 +1,219 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sortKeyAliases keeps the historical --sortBy values working
+var sortKeyAliases = map[string]string{
+	"cpu":    "cpu-usage",
+	"memory": "memory-usage",
+}
+
+// nodeSortKeys maps the --sortBy keys of kr node to a less function. Every key
+// sorts the way it reads best by default: biggest values first, names
+// alphabetically and the oldest nodes first for age.
+var nodeSortKeys = map[string]func(a, b *NodeResources) bool{
+	"name": func(a, b *NodeResources) bool { return a.NodeName < b.NodeName },
+	"cluster": func(a, b *NodeResources) bool {
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		return a.NodeName < b.NodeName
+	},
+	"cpu-usage":               func(a, b *NodeResources) bool { return a.CPUUsages > b.CPUUsages },
+	"cpu-requests":            func(a, b *NodeResources) bool { return a.CPURequests > b.CPURequests },
+	"cpu-limits":              func(a, b *NodeResources) bool { return a.CPULimits > b.CPULimits },
+	"cpu-capacity":            func(a, b *NodeResources) bool { return a.CPUCapacity > b.CPUCapacity },
+	"cpu-request-fraction":    func(a, b *NodeResources) bool { return a.CPURequestsFraction > b.CPURequestsFraction },
+	"cpu-limit-fraction":      func(a, b *NodeResources) bool { return a.CPULimitsFraction > b.CPULimitsFraction },
+	"memory-usage":            func(a, b *NodeResources) bool { return a.MemoryUsages > b.MemoryUsages },
+	"memory-requests":         func(a, b *NodeResources) bool { return a.MemoryRequests > b.MemoryRequests },
+	"memory-limits":           func(a, b *NodeResources) bool { return a.MemoryLimits > b.MemoryLimits },
+	"memory-capacity":         func(a, b *NodeResources) bool { return a.MemoryCapacity > b.MemoryCapacity },
+	"memory-request-fraction": func(a, b *NodeResources) bool { return a.MemoryRequestsFraction > b.MemoryRequestsFraction },
+	"memory-limit-fraction":   func(a, b *NodeResources) bool { return a.MemoryLimitsFraction > b.MemoryLimitsFraction },
+	"pods":                    func(a, b *NodeResources) bool { return a.AllocatedPods > b.AllocatedPods },
+	"pod-fraction":            func(a, b *NodeResources) bool { return a.PodFraction > b.PodFraction },
+	"age":                     func(a, b *NodeResources) bool { return a.CreationTimestamp.Before(&b.CreationTimestamp) },
+}
+
+// podSortKeys maps the --sortBy keys of kr pod to a less function, see nodeSortKeys
+var podSortKeys = map[string]func(a, b *PodsResources) bool{
+	"name": func(a, b *PodsResources) bool { return a.Name < b.Name },
+	"cluster": func(a, b *PodsResources) bool {
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	},
+	"namespace": func(a, b *PodsResources) bool {
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	},
+	"cpu-usage":             func(a, b *PodsResources) bool { return a.CPUUsages > b.CPUUsages },
+	"cpu-requests":          func(a, b *PodsResources) bool { return a.CPURequests > b.CPURequests },
+	"cpu-limits":            func(a, b *PodsResources) bool { return a.CPULimits > b.CPULimits },
+	"cpu-usage-fraction":    func(a, b *PodsResources) bool { return a.CPUUsagesFraction > b.CPUUsagesFraction },
+	"memory-usage":          func(a, b *PodsResources) bool { return a.MemoryUsages > b.MemoryUsages },
+	"memory-requests":       func(a, b *PodsResources) bool { return a.MemoryRequests > b.MemoryRequests },
+	"memory-limits":         func(a, b *PodsResources) bool { return a.MemoryLimits > b.MemoryLimits },
+	"memory-usage-fraction": func(a, b *PodsResources) bool { return a.MemoryUsagesFraction > b.MemoryUsagesFraction },
+	"restarts":              func(a, b *PodsResources) bool { return a.Restarts > b.Restarts },
+	"oom-killed":            func(a, b *PodsResources) bool { return a.OOMKilled > b.OOMKilled },
+	"warnings":              func(a, b *PodsResources) bool { return a.Warnings > b.Warnings },
+	"age":                   func(a, b *PodsResources) bool { return a.CreationTimestamp.Before(&b.CreationTimestamp) },
+}
+
+func normalizeSortKey(by string) string {
+	by = strings.ToLower(by)
+	if alias, ok := sortKeyAliases[by]; ok {
+		return alias
+	}
+	return by
+}
+
+func sortKeys[T any](keys map[string]T) []string {
+	list := make([]string, 0, len(keys))
+	for k := range keys {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// NodeSortKeys returns the keys accepted by SortNodeResources
+func NodeSortKeys() []string {
+	return sortKeys(nodeSortKeys)
+}
+
+// PodSortKeys returns the keys accepted by SortPodsResources
+func PodSortKeys() []string {
+	return sortKeys(podSortKeys)
+}
+
+// ValidateNodeSortKey returns an error when by is not a node sort key
+func ValidateNodeSortKey(by string) error {
+	if _, ok := nodeSortKeys[normalizeSortKey(by)]; !ok {
+		return fmt.Errorf("unknown sort key %q, allowed values: %s", by, strings.Join(NodeSortKeys(), ", "))
+	}
+	return nil
+}
+
+// ValidatePodSortKey returns an error when by is not a pod sort key
+func ValidatePodSortKey(by string) error {
+	if _, ok := podSortKeys[normalizeSortKey(by)]; !ok {
+		return fmt.Errorf("unknown sort key %q, allowed values: %s", by, strings.Join(PodSortKeys(), ", "))
+	}
+	return nil
+}
+
+// SortNodeResources sorts the computed node rows by the given key
+func SortNodeResources(rows []NodeResources, by string, reverse bool) error {
+	if err := ValidateNodeSortKey(by); err != nil {
+		return err
+	}
+	less := nodeSortKeys[normalizeSortKey(by)]
+	sort.SliceStable(rows, func(i, j int) bool {
+		if reverse {
+			return less(&rows[j], &rows[i])
+		}
+		return less(&rows[i], &rows[j])
+	})
+	return nil
+}
+
+// SortPodsResources sorts the computed pod rows by the given key
+func SortPodsResources(rows []PodsResources, by string, reverse bool) error {
+	if err := ValidatePodSortKey(by); err != nil {
+		return err
+	}
+	less := podSortKeys[normalizeSortKey(by)]
+	sort.SliceStable(rows, func(i, j int) bool {
+		if reverse {
+			return less(&rows[j], &rows[i])
+		}
+		return less(&rows[i], &rows[j])
+	})
+	return nil
+}
+
+// row projects the group onto a node row, so that groups sort with the node
+// sort keys, name sorting by value. Keys without a group value, e.g. age, keep
+// the order of the groups.
+func (g *NodeGroup) row() NodeResources {
+	return NodeResources{
+		Cluster:                g.Cluster,
+		NodeName:               g.Value,
+		CPUUsages:              g.CPUUsages,
+		CPURequests:            g.CPURequests,
+		CPULimits:              g.CPULimits,
+		CPUCapacity:            g.CPUCapacity,
+		CPURequestsFraction:    g.CPURequestsFraction,
+		CPULimitsFraction:      g.CPULimitsFraction,
+		MemoryUsages:           g.MemoryUsages,
+		MemoryRequests:         g.MemoryRequests,
+		MemoryLimits:           g.MemoryLimits,
+		MemoryCapacity:         g.MemoryCapacity,
+		MemoryRequestsFraction: g.MemoryRequestsFraction,
+		MemoryLimitsFraction:   g.MemoryLimitsFraction,
+		AllocatedPods:          g.AllocatedPods,
+		PodCapacity:            g.PodCapacity,
+		PodFraction:            g.PodFraction,
+	}
+}
+
+// row projects the group onto a pod row, see NodeGroup.row
+func (g *PodGroup) row() PodsResources {
+	return PodsResources{
+		Cluster:              g.Cluster,
+		Name:                 g.Value,
+		CPUUsages:            g.CPUUsages,
+		CPURequests:          g.CPURequests,
+		CPULimits:            g.CPULimits,
+		CPUUsagesFraction:    g.CPUUsagesFraction,
+		MemoryUsages:         g.MemoryUsages,
+		MemoryRequests:       g.MemoryRequests,
+		MemoryLimits:         g.MemoryLimits,
+		MemoryUsagesFraction: g.MemoryUsagesFraction,
+	}
+}
+
+// SortNodeGroups sorts the node groups by the given node sort key
+func SortNodeGroups(groups []NodeGroup, by string, reverse bool) error {
+	if err := ValidateNodeSortKey(by); err != nil {
+		return err
+	}
+	less := nodeSortKeys[normalizeSortKey(by)]
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].row(), groups[j].row()
+		if reverse {
+			return less(&b, &a)
+		}
+		return less(&a, &b)
+	})
+	return nil
+}
+
+// SortPodGroups sorts the pod groups by the given pod sort key
+func SortPodGroups(groups []PodGroup, by string, reverse bool) error {
+	if err := ValidatePodSortKey(by); err != nil {
+		return err
+	}
+	less := podSortKeys[normalizeSortKey(by)]
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].row(), groups[j].row()
+		if reverse {
+			return less(&b, &a)
+		}
+		return less(&a, &b)
+	})
+	return nil
+}
@@ -0,0 +1,168 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func nodeNames(rows []NodeResources) []string {
+	var names []string
+	for _, r := range rows {
+		names = append(names, r.NodeName)
+	}
+	return names
+}
+
+func podNames(rows []PodsResources) []string {
+	var names []string
+	for _, r := range rows {
+		names = append(names, r.Namespace+"/"+r.Name)
+	}
+	return names
+}
+
+func TestSortNodeResources(t *testing.T) {
+	now := time.Now()
+	rows := func() []NodeResources {
+		return []NodeResources{
+			{NodeName: "b", Cluster: "prod", CPUUsages: 300, MemoryRequestsFraction: 50, AllocatedPods: 10,
+				CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))},
+			{NodeName: "a", Cluster: "prod", CPUUsages: 100, MemoryRequestsFraction: 90, AllocatedPods: 10,
+				CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+			{NodeName: "c", Cluster: "dev", CPUUsages: 200, MemoryRequestsFraction: 10, AllocatedPods: 30,
+				CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour))},
+		}
+	}
+	tests := []struct {
+		by      string
+		reverse bool
+		want    []string
+	}{
+		{"cpu-usage", false, []string{"b", "c", "a"}},
+		{"cpu-usage", true, []string{"a", "c", "b"}},
+		// historical alias of cpu-usage
+		{"CPU", false, []string{"b", "c", "a"}},
+		{"name", false, []string{"a", "b", "c"}},
+		{"name", true, []string{"c", "b", "a"}},
+		{"cluster", false, []string{"c", "a", "b"}},
+		{"memory-request-fraction", false, []string{"a", "b", "c"}},
+		// equal keys keep their order
+		{"pods", false, []string{"c", "b", "a"}},
+		{"pods", true, []string{"b", "a", "c"}},
+		{"age", false, []string{"c", "b", "a"}},
+	}
+	for _, tt := range tests {
+		data := rows()
+		if err := SortNodeResources(data, tt.by, tt.reverse); err != nil {
+			t.Errorf("SortNodeResources(%q) failed: %v", tt.by, err)
+			continue
+		}
+		if got := nodeNames(data); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SortNodeResources(%q, reverse=%v) = %v, want %v", tt.by, tt.reverse, got, tt.want)
+		}
+	}
+}
+
+func TestSortPodsResources(t *testing.T) {
+	rows := func() []PodsResources {
+		return []PodsResources{
+			{Name: "web", Namespace: "default", MemoryUsagesFraction: 80, Restarts: 2},
+			{Name: "dns", Namespace: "kube-system", MemoryUsagesFraction: 95, Restarts: 0},
+			{Name: "api", Namespace: "default", MemoryUsagesFraction: 10, Restarts: 5},
+		}
+	}
+	tests := []struct {
+		by      string
+		reverse bool
+		want    []string
+	}{
+		{"namespace", false, []string{"default/api", "default/web", "kube-system/dns"}},
+		{"namespace", true, []string{"kube-system/dns", "default/web", "default/api"}},
+		{"memory-usage-fraction", false, []string{"kube-system/dns", "default/web", "default/api"}},
+		{"restarts", false, []string{"default/api", "default/web", "kube-system/dns"}},
+		{"restarts", true, []string{"kube-system/dns", "default/web", "default/api"}},
+	}
+	for _, tt := range tests {
+		data := rows()
+		if err := SortPodsResources(data, tt.by, tt.reverse); err != nil {
+			t.Errorf("SortPodsResources(%q) failed: %v", tt.by, err)
+			continue
+		}
+		if got := podNames(data); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SortPodsResources(%q, reverse=%v) = %v, want %v", tt.by, tt.reverse, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSortKey(t *testing.T) {
+	for _, by := range []string{"cpu", "memory", "Memory-Usage", "pod-fraction"} {
+		if err := ValidateNodeSortKey(by); err != nil {
+			t.Errorf("ValidateNodeSortKey(%q) failed: %v", by, err)
+		}
+	}
+	for _, by := range []string{"cpu-usages", "restarts", ""} {
+		if err := ValidateNodeSortKey(by); err == nil {
+			t.Errorf("ValidateNodeSortKey(%q) succeeded, want an error", by)
+		}
+	}
+	if err := ValidatePodSortKey("pod-fraction"); err == nil {
+		t.Errorf("ValidatePodSortKey(%q) succeeded, want an error", "pod-fraction")
+	}
+	if err := SortPodsResources(nil, "nope", false); err == nil {
+		t.Errorf("SortPodsResources(%q) succeeded, want an error", "nope")
+	}
+}
+
+func TestSortGroups(t *testing.T) {
+	nodes := []NodeResources{
+		{NodeName: "a", Cluster: "prod", CPURequests: 100, Labels: map[string]string{"pool": "x"}},
+		{NodeName: "b", Cluster: "prod", CPURequests: 700, Labels: map[string]string{"pool": "y"}},
+		{NodeName: "c", Cluster: "dev", CPURequests: 300, Labels: map[string]string{"pool": "x"}},
+	}
+	groupKeys := func(groups []NodeGroup) []string {
+		var keys []string
+		for _, g := range groups {
+			keys = append(keys, g.Cluster+"/"+g.Value)
+		}
+		return keys
+	}
+	// the nodes of every cluster are grouped apart
+	groups := GroupNodes(nodes, "pool", false)
+	if got, want := groupKeys(groups), []string{"dev/x", "prod/x", "prod/y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupNodes() = %v, want %v", got, want)
+	}
+	tests := []struct {
+		by      string
+		reverse bool
+		want    []string
+	}{
+		{"cpu-requests", false, []string{"prod/y", "dev/x", "prod/x"}},
+		{"cpu-requests", true, []string{"prod/x", "dev/x", "prod/y"}},
+		// groups with the same value keep their order
+		{"name", true, []string{"prod/y", "prod/x", "dev/x"}},
+		{"cluster", false, []string{"dev/x", "prod/x", "prod/y"}},
+	}
+	for _, tt := range tests {
+		if err := SortNodeGroups(groups, tt.by, tt.reverse); err != nil {
+			t.Errorf("SortNodeGroups(%q) failed: %v", tt.by, err)
+			continue
+		}
+		if got := groupKeys(groups); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SortNodeGroups(%q, reverse=%v) = %v, want %v", tt.by, tt.reverse, got, tt.want)
+		}
+	}
+
+	pods := []PodGroup{
+		{Value: "a", PodSummary: PodSummary{MemoryUsagesFraction: 10}},
+		{Value: "b", PodSummary: PodSummary{MemoryUsagesFraction: 90}},
+	}
+	if err := SortPodGroups(pods, "memory-usage-fraction", false); err != nil || pods[0].Value != "b" {
+		t.Errorf("SortPodGroups(%q) = %+v, %v, want b first", "memory-usage-fraction", pods, err)
+	}
+	if err := SortPodGroups(pods, "pod-fraction", false); err == nil {
+		t.Errorf("SortPodGroups(%q) succeeded, want an error", "pod-fraction")
+	}
+}
@@ -0,0 +1,203 @@
+package kube
+
+import "sort"
+
+// NodeSummary aggregates node rows. Fractions are weighted cluster-wide, i.e.
+// computed from the sums rather than averaged per node.
+type NodeSummary struct {
+	Nodes int `json:"nodes" yaml:"nodes"`
+
+	CPUUsages           int64   `json:"cpuUsages" yaml:"cpuUsages"`
+	CPURequests         int64   `json:"cpuRequests" yaml:"cpuRequests"`
+	CPULimits           int64   `json:"cpuLimits" yaml:"cpuLimits"`
+	CPUCapacity         int64   `json:"cpuCapacity" yaml:"cpuCapacity"`
+	CPUUsagesFraction   float64 `json:"cpuUsagesFraction" yaml:"cpuUsagesFraction"`
+	CPURequestsFraction float64 `json:"cpuRequestsFraction" yaml:"cpuRequestsFraction"`
+	CPULimitsFraction   float64 `json:"cpuLimitsFraction" yaml:"cpuLimitsFraction"`
+
+	MemoryUsages           int64   `json:"memoryUsages" yaml:"memoryUsages"`
+	MemoryRequests         int64   `json:"memoryRequests" yaml:"memoryRequests"`
+	MemoryLimits           int64   `json:"memoryLimits" yaml:"memoryLimits"`
+	MemoryCapacity         int64   `json:"memoryCapacity" yaml:"memoryCapacity"`
+	MemoryUsagesFraction   float64 `json:"memoryUsagesFraction" yaml:"memoryUsagesFraction"`
+	MemoryRequestsFraction float64 `json:"memoryRequestsFraction" yaml:"memoryRequestsFraction"`
+	MemoryLimitsFraction   float64 `json:"memoryLimitsFraction" yaml:"memoryLimitsFraction"`
+
+	AllocatedPods int     `json:"allocatedPods" yaml:"allocatedPods"`
+	PodCapacity   int64   `json:"podCapacity" yaml:"podCapacity"`
+	PodFraction   float64 `json:"podFraction" yaml:"podFraction"`
+
+	QOS QOSBreakdown `json:"qos" yaml:"qos"`
+
+	// NoMetrics counts the nodes without metrics, the usage fractions are
+	// relative to the capacity of the other nodes
+	NoMetrics int `json:"noMetrics" yaml:"noMetrics"`
+
+	// Warning and Critical count the rows whose highlighted fractions cross
+	// the warning or critical threshold
+	Warning  int `json:"warning" yaml:"warning"`
+	Critical int `json:"critical" yaml:"critical"`
+}
+
+// PodSummary aggregates pod rows, see NodeSummary
+type PodSummary struct {
+	Pods int `json:"pods" yaml:"pods"`
+
+	CPUUsages         int64   `json:"cpuUsages" yaml:"cpuUsages"`
+	CPURequests       int64   `json:"cpuRequests" yaml:"cpuRequests"`
+	CPULimits         int64   `json:"cpuLimits" yaml:"cpuLimits"`
+	CPUUsagesFraction float64 `json:"cpuUsagesFraction" yaml:"cpuUsagesFraction"`
+
+	MemoryUsages         int64   `json:"memoryUsages" yaml:"memoryUsages"`
+	MemoryRequests       int64   `json:"memoryRequests" yaml:"memoryRequests"`
+	MemoryLimits         int64   `json:"memoryLimits" yaml:"memoryLimits"`
+	MemoryUsagesFraction float64 `json:"memoryUsagesFraction" yaml:"memoryUsagesFraction"`
+
+	// NoMetrics counts the pods without metrics, the usage fractions only
+	// weigh the usage of the other pods against their limits
+	NoMetrics int `json:"noMetrics" yaml:"noMetrics"`
+
+	Warning  int `json:"warning" yaml:"warning"`
+	Critical int `json:"critical" yaml:"critical"`
+}
+
+// severity counts a row as critical or warning based on its worst fraction
+func severity(warning, critical *int, fractions ...float64) {
+	worst := float64(0)
+	for _, f := range fractions {
+		if f > worst {
+			worst = f
+		}
+	}
+	switch {
+	case worst > criticalThreshold:
+		*critical++
+	case worst > warningThreshold:
+		*warning++
+	}
+}
+
+// SummarizeNodes sums the node rows
+func SummarizeNodes(rows []NodeResources) NodeSummary {
+	s := NodeSummary{Nodes: len(rows)}
+	// capacity of the nodes reporting metrics
+	var cpuMeasured, memoryMeasured int64
+	for _, r := range rows {
+		if r.NoMetrics {
+			s.NoMetrics++
+		} else {
+			cpuMeasured += r.CPUCapacity
+			memoryMeasured += r.MemoryCapacity
+		}
+		s.CPUUsages += r.CPUUsages
+		s.CPURequests += r.CPURequests
+		s.CPULimits += r.CPULimits
+		s.CPUCapacity += r.CPUCapacity
+		s.MemoryUsages += r.MemoryUsages
+		s.MemoryRequests += r.MemoryRequests
+		s.MemoryLimits += r.MemoryLimits
+		s.MemoryCapacity += r.MemoryCapacity
+		s.AllocatedPods += r.AllocatedPods
+		s.PodCapacity += r.PodCapacity
+		s.QOS.add(r.QOS)
+		severity(&s.Warning, &s.Critical, r.CPURequestsFraction, r.MemoryRequestsFraction, r.PodFraction)
+	}
+	s.CPUUsagesFraction = calcPercentage(s.CPUUsages, cpuMeasured)
+	s.CPURequestsFraction = calcPercentage(s.CPURequests, s.CPUCapacity)
+	s.CPULimitsFraction = calcPercentage(s.CPULimits, s.CPUCapacity)
+	s.MemoryUsagesFraction = calcPercentage(s.MemoryUsages, memoryMeasured)
+	s.MemoryRequestsFraction = calcPercentage(s.MemoryRequests, s.MemoryCapacity)
+	s.MemoryLimitsFraction = calcPercentage(s.MemoryLimits, s.MemoryCapacity)
+	s.PodFraction = calcPercentage(int64(s.AllocatedPods), s.PodCapacity)
+	return s
+}
+
+// SummarizePods sums the pod rows. Usage fractions are the summed usage of the
+// pods with a limit relative to their summed limits, the usage of pods without
+// a limit for the resource is left out of them.
+func SummarizePods(rows []PodsResources) PodSummary {
+	s := PodSummary{Pods: len(rows)}
+	// usage and limits of the pods reporting metrics and having a limit
+	var cpuMeasured, cpuLimited, memoryMeasured, memoryLimited int64
+	for _, r := range rows {
+		if r.NoMetrics {
+			s.NoMetrics++
+		} else {
+			if r.CPULimits > 0 {
+				cpuMeasured += r.CPUUsages
+				cpuLimited += r.CPULimits
+			}
+			if r.MemoryLimits > 0 {
+				memoryMeasured += r.MemoryUsages
+				memoryLimited += r.MemoryLimits
+			}
+		}
+		s.CPUUsages += r.CPUUsages
+		s.CPURequests += r.CPURequests
+		s.CPULimits += r.CPULimits
+		s.MemoryUsages += r.MemoryUsages
+		s.MemoryRequests += r.MemoryRequests
+		s.MemoryLimits += r.MemoryLimits
+		severity(&s.Warning, &s.Critical, r.CPUUsagesFraction, r.MemoryUsagesFraction)
+	}
+	s.CPUUsagesFraction = calcPercentage(cpuMeasured, cpuLimited)
+	s.MemoryUsagesFraction = calcPercentage(memoryMeasured, memoryLimited)
+	return s
+}
+
+// NodeGroup aggregates the nodes sharing the same value of a label
+type NodeGroup struct {
+	// Cluster is the kubeconfig context of multi-cluster reports, the nodes
+	// of every cluster are grouped apart
+	Cluster string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Label   string `json:"label" yaml:"label"`
+	Value   string `json:"value" yaml:"value"`
+	NodeSummary
+	// Items lists the member nodes when the group is expanded
+	Items []NodeResources `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+// groupKey identifies a group by cluster and label value
+type groupKey struct {
+	cluster, value string
+}
+
+// sortGroupKeys orders the groups by cluster, then by value
+func sortGroupKeys(keys []groupKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].cluster != keys[j].cluster {
+			return keys[i].cluster < keys[j].cluster
+		}
+		return keys[i].value < keys[j].value
+	})
+}
+
+// GroupNodes collapses the node rows by cluster and value of label, nodes
+// without the label are gathered in a <none> group. Groups are ordered by
+// cluster and value and keep the order of their member rows.
+func GroupNodes(rows []NodeResources, label string, expand bool) []NodeGroup {
+	members := map[groupKey][]NodeResources{}
+	var keys []groupKey
+	for _, r := range rows {
+		value, ok := r.Labels[label]
+		if !ok {
+			value = none
+		}
+		key := groupKey{cluster: r.Cluster, value: value}
+		if _, ok := members[key]; !ok {
+			keys = append(keys, key)
+		}
+		members[key] = append(members[key], r)
+	}
+	sortGroupKeys(keys)
+
+	groups := make([]NodeGroup, 0, len(keys))
+	for _, key := range keys {
+		g := NodeGroup{Cluster: key.cluster, Label: label, Value: key.value, NodeSummary: SummarizeNodes(members[key])}
+		if expand {
+			g.Items = members[key]
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
@@ -0,0 +1,27 @@
+package kube
+
+import "testing"
+
+func TestSummarizePods(t *testing.T) {
+	rows := []PodsResources{
+		{Name: "web", CPUUsages: 500, CPULimits: 1000, MemoryUsages: 512, MemoryLimits: 1024},
+		// no limits, its usage is left out of the fractions
+		{Name: "batch", CPUUsages: 4000, MemoryUsages: 8192},
+		// a memory limit only
+		{Name: "api", CPUUsages: 1000, MemoryUsages: 256, MemoryLimits: 1024},
+		{Name: "fresh", CPULimits: 1000, MemoryLimits: 1024, NoMetrics: true},
+	}
+	s := SummarizePods(rows)
+	if s.CPUUsages != 5500 || s.MemoryUsages != 8960 {
+		t.Errorf("SummarizePods() usage = %d cpu, %d memory, want 5500 and 8960", s.CPUUsages, s.MemoryUsages)
+	}
+	if s.CPUUsagesFraction != 50 {
+		t.Errorf("SummarizePods() cpu usage fraction = %v, want 50", s.CPUUsagesFraction)
+	}
+	if s.MemoryUsagesFraction != 37.5 {
+		t.Errorf("SummarizePods() memory usage fraction = %v, want 37.5", s.MemoryUsagesFraction)
+	}
+	if s.NoMetrics != 1 {
+		t.Errorf("SummarizePods() no metrics = %d, want 1", s.NoMetrics)
+	}
+}
@@ -14,6 +14,7 @@ limitations under the License.
 package output
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -28,13 +29,15 @@ type Format string
 
 const (
 	Table Format = "table"
+	Wide  Format = "wide"
+	CSV   Format = "csv"
 	JSON  Format = "json"
 	YAML  Format = "yaml"
 )
 
 // Formats returns a list of the string representation of the supported formats
 func Formats() []string {
-	return []string{Table.String(), JSON.String(), YAML.String()}
+	return []string{Table.String(), Wide.String(), CSV.String(), JSON.String(), YAML.String()}
 }
 
 // FormatsWithDesc returns a list of the string representation of the supported formats
@@ -42,6 +45,8 @@ func Formats() []string {
 func FormatsWithDesc() map[string]string {
 	return map[string]string{
 		Table.String(): "Output result in human-readable format",
+		Wide.String():  "Output result in human-readable format with additional columns",
+		CSV.String():   "Output result in CSV format",
 		JSON.String():  "Output result in JSON format",
 		YAML.String():  "Output result in YAML format",
 	}
@@ -59,7 +64,7 @@ func (o Format) String() string {
 // will return an error
 func (o Format) Write(out io.Writer, w Writer) error {
 	switch o {
-	case Table:
+	case Table, Wide:
 		return w.WriteTable(out)
 	case JSON:
 		return w.WriteJSON(out)
@@ -75,6 +80,10 @@ func ParseFormat(s string) (out Format, err error) {
 	switch s {
 	case Table.String():
 		out, err = Table, nil
+	case Wide.String():
+		out, err = Wide, nil
+	case CSV.String():
+		out, err = CSV, nil
 	case JSON.String():
 		out, err = JSON, nil
 	case YAML.String():
@@ -135,3 +144,23 @@ func EncodeTable(out io.Writer, table *uitable.Table) error {
 	}
 	return nil
 }
+
+// EncodeCSV is a helper function to decorate any error message with a bit
+// more context and avoid writing the same code over and over for printers
+func EncodeCSV(out io.Writer, rows [][]interface{}) error {
+	w := csv.NewWriter(out)
+	for _, row := range rows {
+		record := make([]string, 0, len(row))
+		for _, cell := range row {
+			record = append(record, fmt.Sprintf("%v", cell))
+		}
+		if err := w.Write(record); err != nil {
+			return errors.Wrap(err, "unable to write CSV output")
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return errors.Wrap(err, "unable to write CSV output")
+	}
+	return nil
+}
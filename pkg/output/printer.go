@@ -0,0 +1,101 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/kubectl/pkg/cmd/get"
+)
+
+// Template based formats, given as "<format>=<argument>" the same way kubectl
+// accepts them
+const (
+	CustomColumns     Format = "custom-columns"
+	CustomColumnsFile Format = "custom-columns-file"
+	GoTemplate        Format = "go-template"
+	GoTemplateFile    Format = "go-template-file"
+	JSONPath          Format = "jsonpath"
+	JSONPathFile      Format = "jsonpath-file"
+)
+
+// TemplateFormats returns a list of the string representation of the supported
+// template based formats
+func TemplateFormats() []string {
+	return []string{
+		CustomColumns.String(), CustomColumnsFile.String(),
+		GoTemplate.String(), GoTemplateFile.String(),
+		JSONPath.String(), JSONPathFile.String(),
+	}
+}
+
+// ParsePrinter takes a raw output spec such as "jsonpath={.items[*].name}" and
+// returns the matching printer. A nil printer is returned when the spec does
+// not name a template based format.
+func ParsePrinter(spec string) (printers.ResourcePrinter, error) {
+	name, arg, _ := strings.Cut(spec, "=")
+	switch Format(name) {
+	case CustomColumns, GoTemplate, JSONPath:
+	case CustomColumnsFile, GoTemplateFile, JSONPathFile:
+		raw, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s", name)
+		}
+		arg = string(raw)
+	default:
+		return nil, nil
+	}
+	if len(arg) == 0 {
+		return nil, fmt.Errorf("%s format specified but no template given", name)
+	}
+
+	switch Format(name) {
+	case CustomColumns:
+		return get.NewCustomColumnsPrinterFromSpec(arg, unstructured.UnstructuredJSONScheme, false)
+	case CustomColumnsFile:
+		return get.NewCustomColumnsPrinterFromTemplate(strings.NewReader(arg), unstructured.UnstructuredJSONScheme)
+	case GoTemplate, GoTemplateFile:
+		p, err := printers.NewGoTemplatePrinter([]byte(arg))
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing template")
+		}
+		p.AllowMissingKeys(true)
+		return p, nil
+	default:
+		p, err := printers.NewJSONPathPrinter(arg)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing jsonpath")
+		}
+		p.AllowMissingKeys(true)
+		return p, nil
+	}
+}
+
+// EncodePrinter is a helper function to render a list of typed results with
+// a template based printer. The items are exposed as a kubectl style List, so
+// templates address them through ".items".
+func EncodePrinter(out io.Writer, p printers.ResourcePrinter, items interface{}) error {
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return errors.Wrap(err, "unable to write template output")
+	}
+	// utiljson keeps integers as int64 instead of float64, so byte counts are
+	// not printed in exponent notation
+	var objs []map[string]interface{}
+	if err := utiljson.Unmarshal(raw, &objs); err != nil {
+		return errors.Wrap(err, "unable to write template output")
+	}
+	list := &unstructured.UnstructuredList{
+		Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"},
+	}
+	for _, obj := range objs {
+		list.Items = append(list.Items, unstructured.Unstructured{Object: obj})
+	}
+	return p.PrintObj(list, out)
+}
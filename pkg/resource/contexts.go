@@ -0,0 +1,103 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+)
+
+// validateContexts rejects the multi-cluster flags where they do not apply
+func validateContexts(contexts []string, all bool, kubeCtx, snapshot string) error {
+	if len(contexts) == 0 && !all {
+		return nil
+	}
+	if len(contexts) > 0 && all {
+		return fmt.Errorf("--contexts and --all-contexts are mutually exclusive")
+	}
+	if len(kubeCtx) > 0 {
+		return fmt.Errorf("--context can not be combined with --contexts or --all-contexts")
+	}
+	if len(snapshot) > 0 {
+		return fmt.Errorf("--from-snapshot can not be combined with --contexts or --all-contexts")
+	}
+	return nil
+}
+
+// resolveContexts returns the contexts to report on, none when the report
+// runs against a single cluster
+func resolveContexts(contexts []string, all bool, kubeconfig string) ([]string, error) {
+	if !all {
+		return contexts, nil
+	}
+	contexts, err := kube.Contexts(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("no context found in the kubeconfig")
+	}
+	return contexts, nil
+}
+
+// fetchContexts runs fetch against every context concurrently, one client per
+// context, and concatenates the rows in the order of the contexts. A failing
+// context is reported on stderr and skipped, the report only fails when every
+// context does.
+func fetchContexts[T any](contexts []string, kubeconfig string, fetch func(k *kube.KubeClient, cluster string) ([]T, error)) ([]T, error) {
+	results := make([][]T, len(contexts))
+	errs := make([]error, len(contexts))
+	var wg sync.WaitGroup
+	for i, name := range contexts {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			cfg := kube.ClientConfig{
+				KubeCtx:    name,
+				KubeConfig: kubeconfig,
+			}
+			k, err := kube.NewKubeClient(&cfg)
+			if err == nil {
+				results[i], err = fetch(k, name)
+			}
+			errs[i] = err
+		}(i, name)
+	}
+	wg.Wait()
+
+	var rows []T
+	var lastErr error
+	for i, name := range contexts {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't report on context %s: %s\n", name, errs[i])
+			lastErr = errs[i]
+			continue
+		}
+		rows = append(rows, results[i]...)
+	}
+	if lastErr != nil && allFailed(errs) {
+		return nil, fmt.Errorf("every context failed, last error: %w", lastErr)
+	}
+	return rows, nil
+}
+
+func allFailed(errs []error) bool {
+	for _, err := range errs {
+		if err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// clusterRows returns the rows of one cluster
+func clusterRows[T any](rows []T, cluster func(*T) string, name string) []T {
+	var selected []T
+	for i := range rows {
+		if cluster(&rows[i]) == name {
+			selected = append(selected, rows[i])
+		}
+	}
+	return selected
+}
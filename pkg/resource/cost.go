@@ -0,0 +1,155 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/output"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type CostOption struct {
+	// Prices is the price table file, see kube.PriceTable
+	Prices    string
+	By        string
+	PoolLabel string
+	// Hours scales the hourly prices, e.g. 730 for a month
+	Hours      float64
+	Namespace  string
+	Selector   string
+	Top        int
+	KubeCtx    string
+	KubeConfig string
+	Snapshot   string
+	Output     string
+	Lang       string
+}
+
+func (o *CostOption) Validate() error {
+	if err := i18n.Validate(o.Lang); err != nil {
+		return err
+	}
+	if len(o.Prices) == 0 {
+		return fmt.Errorf("a price table is required, see --prices")
+	}
+	if o.Hours <= 0 {
+		return fmt.Errorf("--hours must be positive")
+	}
+	return kube.ValidateCostRollUp(o.By)
+}
+
+// costReport is the document printed in JSON and YAML output
+type costReport struct {
+	Currency string          `json:"currency,omitempty" yaml:"currency,omitempty"`
+	Hours    float64         `json:"hours" yaml:"hours"`
+	By       string          `json:"by" yaml:"by"`
+	Items    []kube.CostItem `json:"items" yaml:"items"`
+	Total    kube.CostItem   `json:"total" yaml:"total"`
+	Pools    []kube.PoolCost `json:"pools" yaml:"pools"`
+	Unpriced []string        `json:"unpriced,omitempty" yaml:"unpriced,omitempty"`
+}
+
+func (o *CostOption) RunCost() error {
+	selector := labels.Everything()
+	var err error
+	if len(o.Selector) > 0 {
+		selector, err = labels.Parse(o.Selector)
+		if err != nil {
+			return err
+		}
+	}
+	table, err := kube.LoadPriceTable(o.Prices)
+	if err != nil {
+		return err
+	}
+	if len(o.PoolLabel) > 0 {
+		table.PoolLabel = o.PoolLabel
+	}
+	table.Scale(o.Hours)
+	cfg := kube.ClientConfig{
+		KubeCtx:    o.KubeCtx,
+		KubeConfig: o.KubeConfig,
+		Snapshot:   o.Snapshot,
+	}
+	k, err := kube.NewKubeClient(&cfg)
+	if err != nil {
+		return err
+	}
+	costs, err := k.GetCosts(table, selector)
+	if err != nil {
+		return err
+	}
+	pods := costs.Pods
+	if len(o.Namespace) > 0 {
+		pods = nil
+		for _, c := range costs.Pods {
+			if c.Namespace == o.Namespace {
+				pods = append(pods, c)
+			}
+		}
+	}
+	items := kube.RollUpCosts(pods, o.By)
+	keys := len(items)
+	total := kube.CostItem{Key: "total"}
+	for _, item := range items {
+		total.Pods += item.Pods
+		total.CPU += item.CPU
+		total.Memory += item.Memory
+		total.GPU += item.GPU
+		total.Total += item.Total
+	}
+	items, err = selectRows(items, nil, o.Top)
+	if err != nil {
+		return err
+	}
+	report := costReport{Currency: costs.Currency, Hours: o.Hours, By: o.By, Items: items, Total: total, Pools: costs.Pools, Unpriced: costs.Unpriced}
+	switch strings.ToLower(o.Output) {
+	case "json":
+		return output.EncodeJSON(os.Stdout, report)
+	case "yaml":
+		return output.EncodeYAML(os.Stdout, report)
+	default:
+		format := output.Format(strings.ToLower(o.Output))
+		lang := i18n.Detect(o.Lang)
+		if len(costs.Unpriced) > 0 {
+			fmt.Fprintf(os.Stderr, lang.T("unpriced")+"\n", len(costs.Unpriced), kube.JoinOrNone(costs.Unpriced))
+		}
+		rows := [][]interface{}{append(lang.Headers(costRollUpHeader(o.By)),
+			lang.Headers("pods", "cpuCost", "memoryCost", "gpuCost", "totalCost")...)}
+		for _, d := range items {
+			rows = append(rows, []interface{}{d.Key, d.Pods, money(d.CPU), money(d.Memory), money(d.GPU), money(d.Total)})
+		}
+		rows = append(rows, []interface{}{fmt.Sprintf("%s(%d)", lang.T("total"), keys), total.Pods,
+			money(total.CPU), money(total.Memory), money(total.GPU), money(total.Total)})
+		if err := writeRows(os.Stdout, format, rows); err != nil {
+			return err
+		}
+		if format == output.CSV {
+			return nil
+		}
+		fmt.Fprintln(os.Stdout)
+		rows = [][]interface{}{lang.Headers("pool", "nodes", "nodeCost", "allocatedCost", "idleCost")}
+		for _, p := range costs.Pools {
+			rows = append(rows, []interface{}{p.Pool, p.Nodes, money(p.Cost), money(p.Allocated),
+				fractionCell{value: money(p.Idle), fraction: kube.FractionString(p.IdleFraction)}})
+		}
+		return writeRows(os.Stdout, format, rows)
+	}
+}
+
+// costRollUpHeader returns the message id of the first column, the label key
+// itself for label roll-ups
+func costRollUpHeader(by string) string {
+	if key := strings.TrimPrefix(by, kube.CostRollUpLabelPrefix); key != by {
+		return key
+	}
+	return by
+}
+
+// money renders a cost with two decimals
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
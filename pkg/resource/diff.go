@@ -0,0 +1,234 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/output"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	diffKindNode = "node"
+	diffKindPod  = "pod"
+)
+
+type DiffOption struct {
+	Before  string
+	After   string
+	Kind    string
+	GroupBy string
+	SortBy  string
+	Top     int
+	Output  string
+	Lang    string
+	Units   string
+}
+
+func (o *DiffOption) Validate() error {
+	if err := i18n.Validate(o.Lang); err != nil {
+		return err
+	}
+	switch o.Kind {
+	case "", diffKindNode, diffKindPod:
+	default:
+		return fmt.Errorf("unknown --kind %q, allowed values: node, pod", o.Kind)
+	}
+	if len(o.GroupBy) > 0 && o.Kind == diffKindPod {
+		return fmt.Errorf("--group-by only applies to nodes")
+	}
+	return kube.ValidateDeltaSortKey(o.SortBy)
+}
+
+// diffInput is one side of kr diff: the rows printed by kr node -o json or
+// kr pod -o json, or a snapshot holding both
+type diffInput struct {
+	kind  string
+	nodes []kube.NodeResources
+	pods  []kube.PodsResources
+}
+
+// loadDiffInput reads a report or a snapshot, the kind of an empty report is
+// left undecided
+func loadDiffInput(filename string) (*diffInput, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if bytes.HasPrefix(raw, []byte("{")) {
+		var doc struct {
+			Items json.RawMessage `json:"items"`
+			Nodes json.RawMessage `json:"nodes"`
+		}
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse %s", filename)
+		}
+		if doc.Nodes != nil {
+			return loadSnapshotDiffInput(filename)
+		}
+		// the document printed with --summary
+		raw = doc.Items
+	}
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %s, expected the output of kr node -o json or kr pod -o json", filename)
+	}
+	in := &diffInput{}
+	if len(rows) == 0 {
+		return in, nil
+	}
+	has := func(key string) bool { _, ok := rows[0][key]; return ok }
+	switch {
+	case has("nodeName") && has("cpuCapacity"):
+		in.kind = diffKindNode
+		err = json.Unmarshal(raw, &in.nodes)
+	case has("namespace") && has("name"):
+		in.kind = diffKindPod
+		err = json.Unmarshal(raw, &in.pods)
+	default:
+		return nil, fmt.Errorf("%s holds neither nodes nor pods, grouped reports can not be compared", filename)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %s", filename)
+	}
+	return in, nil
+}
+
+// loadSnapshotDiffInput computes the node and pod rows of a snapshot
+func loadSnapshotDiffInput(filename string) (*diffInput, error) {
+	k, err := kube.NewKubeClient(&kube.ClientConfig{Snapshot: filename})
+	if err != nil {
+		return nil, err
+	}
+	in := &diffInput{}
+	in.nodes, err = k.GetNodeResources(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	in.pods, err = k.GetPodResources("", labels.Everything(), kube.PodPhaseSelector(""))
+	if err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// resolveKind picks what to compare: the --kind flag, else the kind of the
+// reports, nodes for two snapshots
+func (o *DiffOption) resolveKind(before, after *diffInput) (string, error) {
+	kind := o.Kind
+	for _, in := range []*diffInput{before, after} {
+		if len(in.kind) == 0 {
+			continue
+		}
+		if len(kind) > 0 && kind != in.kind {
+			return "", fmt.Errorf("can not compare %s rows with %s rows", kind, in.kind)
+		}
+		kind = in.kind
+	}
+	if len(kind) == 0 {
+		kind = diffKindNode
+	}
+	return kind, nil
+}
+
+func (o *DiffOption) RunDiff() error {
+	units, err := kube.ParseUnits(o.Units)
+	if err != nil {
+		return err
+	}
+	before, err := loadDiffInput(o.Before)
+	if err != nil {
+		return err
+	}
+	after, err := loadDiffInput(o.After)
+	if err != nil {
+		return err
+	}
+	kind, err := o.resolveKind(before, after)
+	if err != nil {
+		return err
+	}
+	var deltas []kube.ResourceDelta
+	if kind == diffKindNode {
+		deltas = kube.DiffNodes(before.nodes, after.nodes, o.GroupBy)
+	} else {
+		deltas = kube.DiffPods(before.pods, after.pods)
+	}
+	if err := kube.SortDeltas(deltas, o.SortBy); err != nil {
+		return err
+	}
+	// the total covers every change, not only the ones --top shows
+	total := kube.SumDeltas(deltas)
+	deltas, err = selectRows(deltas, nil, o.Top)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(o.Output) {
+	case "json":
+		return output.EncodeJSON(os.Stdout, deltas)
+	case "yaml":
+		return output.EncodeYAML(os.Stdout, deltas)
+	default:
+		format := output.Format(strings.ToLower(o.Output))
+		f := newCellFormatter(units, format)
+		lang := i18n.Detect(o.Lang)
+		var key interface{} = lang.T("workload")
+		switch {
+		case kind == diffKindNode && len(o.GroupBy) > 0:
+			key = o.GroupBy
+		case kind == diffKindNode:
+			key = lang.T("name")
+		}
+		header := append([]interface{}{key}, lang.Headers("status", "cpuUsages", "cpuRequests", "cpuLimits",
+			"memoryUsages", "memoryRequests", "memoryLimits", "pods")...)
+		if kind == diffKindNode {
+			header = append(header, lang.Headers("cpuCapacity", "memoryCapacity")...)
+		}
+		rows := [][]interface{}{header}
+		for _, d := range deltas {
+			rows = append(rows, deltaRow(f, d, d.Key, d.Status, kind))
+		}
+		rows = append(rows, deltaRow(f, total, lang.T("total"), "", kind))
+		return writeRows(os.Stdout, format, rows)
+	}
+}
+
+// deltaRow renders a change as signed table cells
+func deltaRow(f cellFormatter, d kube.ResourceDelta, key, status, kind string) []interface{} {
+	row := []interface{}{key, status,
+		f.cpuDelta(d.CPUUsages), f.cpuDelta(d.CPURequests), f.cpuDelta(d.CPULimits),
+		f.memoryDelta(d.MemoryUsages), f.memoryDelta(d.MemoryRequests), f.memoryDelta(d.MemoryLimits),
+		signed(int64(d.Pods), func(v int64) string { return fmt.Sprint(v) })}
+	if kind == diffKindNode {
+		row = append(row, f.cpuDelta(d.CPUCapacity), f.memoryDelta(d.MemoryCapacity))
+	}
+	return row
+}
+
+// signed prefixes a rendered magnitude with the sign of v
+func signed(v int64, render func(int64) string) string {
+	switch {
+	case v > 0:
+		return "+" + render(v)
+	case v < 0:
+		return "-" + render(-v)
+	}
+	return "0"
+}
+
+// cpuDelta renders a change of millicores, e.g. +14
+func (f cellFormatter) cpuDelta(v int64) string {
+	return signed(v, f.cpu)
+}
+
+// memoryDelta renders a change of bytes, e.g. -512Mi
+func (f cellFormatter) memoryDelta(v int64) string {
+	return signed(v, f.memory)
+}
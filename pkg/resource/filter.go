@@ -0,0 +1,43 @@
+package resource
+
+import (
+	"github.com/ysicing/kubectl-resource/pkg/filter"
+)
+
+// parseWhere compiles the --where flag against the fields of the rows T, an
+// empty flag matches everything
+func parseWhere[T any](where string) (*filter.Expr, error) {
+	if len(where) == 0 {
+		return nil, nil
+	}
+	expr, err := filter.Parse(where)
+	if err != nil {
+		return nil, err
+	}
+	var row T
+	if err := expr.Bind(row); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// selectRows keeps the rows matching where, then at most top of them
+func selectRows[T any](rows []T, where *filter.Expr, top int) ([]T, error) {
+	if where != nil {
+		selected := rows[:0]
+		for _, row := range rows {
+			ok, err := where.Match(row)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				selected = append(selected, row)
+			}
+		}
+		rows = selected
+	}
+	if top > 0 && len(rows) > top {
+		rows = rows[:top]
+	}
+	return rows, nil
+}
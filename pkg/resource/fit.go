@@ -0,0 +1,224 @@
+package resource
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/output"
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+type FitOption struct {
+	Filename   string
+	CPU        string
+	Memory     string
+	Replicas   int
+	Selector   string
+	KubeCtx    string
+	KubeConfig string
+	Snapshot   string
+	Output     string
+	Lang       string
+	Units      string
+}
+
+func (o *FitOption) Validate() error {
+	if err := i18n.Validate(o.Lang); err != nil {
+		return err
+	}
+	if len(o.Filename) == 0 && len(o.CPU) == 0 && len(o.Memory) == 0 {
+		return fmt.Errorf("either -f or at least one of --cpu and --memory is required")
+	}
+	if o.Replicas < 0 {
+		return fmt.Errorf("--replicas must not be negative")
+	}
+	return nil
+}
+
+// request builds the fit request from the manifest, the flags overriding its
+// requests and replicas
+func (o *FitOption) request() (kube.FitRequest, error) {
+	req := kube.FitRequest{Replicas: 1}
+	if len(o.Filename) > 0 {
+		spec, replicas, err := loadPodSpec(o.Filename)
+		if err != nil {
+			return req, err
+		}
+		reqs, _, err := kube.PodRequestsAndLimits(&corev1.Pod{Spec: spec})
+		if err != nil {
+			return req, err
+		}
+		req.Spec = spec
+		req.CPU = reqs.Cpu().MilliValue()
+		req.Memory = reqs.Memory().Value()
+		if replicas > 0 {
+			req.Replicas = replicas
+		}
+	}
+	if len(o.CPU) > 0 {
+		q, err := k8sresource.ParseQuantity(o.CPU)
+		if err != nil {
+			return req, errors.Wrap(err, "invalid --cpu")
+		}
+		req.CPU = q.MilliValue()
+	}
+	if len(o.Memory) > 0 {
+		q, err := k8sresource.ParseQuantity(o.Memory)
+		if err != nil {
+			return req, errors.Wrap(err, "invalid --memory")
+		}
+		req.Memory = q.Value()
+	}
+	if o.Replicas > 0 {
+		req.Replicas = o.Replicas
+	}
+	return req, nil
+}
+
+// jobSpec is the part of a Job spec, also embedded in CronJobs, sizing the fit
+type jobSpec struct {
+	Parallelism *int32                 `json:"parallelism"`
+	Completions *int32                 `json:"completions"`
+	Template    corev1.PodTemplateSpec `json:"template"`
+}
+
+// podTemplateManifest covers Pods as well as the workloads embedding a pod
+// template (Deployment, StatefulSet, ReplicaSet, Job, CronJob)
+type podTemplateManifest struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Replicas *int32 `json:"replicas"`
+		jobSpec
+		JobTemplate struct {
+			Spec jobSpec `json:"spec"`
+		} `json:"jobTemplate"`
+	} `json:"spec"`
+}
+
+// replicas returns the pods a Job runs at once, its parallelism capped by its
+// completions, 1 by default
+func (s jobSpec) replicas() int {
+	replicas := 1
+	if s.Parallelism != nil {
+		replicas = int(*s.Parallelism)
+	}
+	if s.Completions != nil && int(*s.Completions) < replicas {
+		replicas = int(*s.Completions)
+	}
+	return replicas
+}
+
+// loadPodSpec reads the pod spec and replica count of the first workload of a
+// manifest, other objects such as Services are skipped, "-" reads stdin
+func loadPodSpec(filename string) (corev1.PodSpec, int, error) {
+	var in io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return corev1.PodSpec{}, 0, err
+		}
+		defer f.Close()
+		in = f
+	}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+	for {
+		raw, err := reader.Read()
+		if err == io.EOF {
+			return corev1.PodSpec{}, 0, fmt.Errorf("%s holds neither a pod nor a pod template", filename)
+		}
+		if err != nil {
+			return corev1.PodSpec{}, 0, errors.Wrapf(err, "unable to read %s", filename)
+		}
+		var manifest podTemplateManifest
+		if err := yaml.Unmarshal(raw, &manifest); err != nil {
+			return corev1.PodSpec{}, 0, errors.Wrapf(err, "unable to parse %s", filename)
+		}
+		switch strings.ToLower(manifest.Kind) {
+		case "pod":
+			var pod corev1.Pod
+			if err := yaml.Unmarshal(raw, &pod); err != nil {
+				return corev1.PodSpec{}, 0, errors.Wrapf(err, "unable to parse %s", filename)
+			}
+			return pod.Spec, 1, nil
+		case "daemonset":
+			return corev1.PodSpec{}, 0, fmt.Errorf("%s holds a DaemonSet, it runs one pod per matching node rather than replicas, use --cpu and --memory with --replicas instead", filename)
+		case "job":
+			return manifest.Spec.Template.Spec, manifest.Spec.replicas(), nil
+		case "cronjob":
+			job := manifest.Spec.JobTemplate.Spec
+			return job.Template.Spec, job.replicas(), nil
+		}
+		if len(manifest.Spec.Template.Spec.Containers) == 0 {
+			continue
+		}
+		replicas := 0
+		if manifest.Spec.Replicas != nil {
+			replicas = int(*manifest.Spec.Replicas)
+		}
+		return manifest.Spec.Template.Spec, replicas, nil
+	}
+}
+
+func (o *FitOption) RunFit() error {
+	selector := labels.Everything()
+	var err error
+	if len(o.Selector) > 0 {
+		selector, err = labels.Parse(o.Selector)
+		if err != nil {
+			return err
+		}
+	}
+	units, err := kube.ParseUnits(o.Units)
+	if err != nil {
+		return err
+	}
+	req, err := o.request()
+	if err != nil {
+		return err
+	}
+	cfg := kube.ClientConfig{
+		KubeCtx:    o.KubeCtx,
+		KubeConfig: o.KubeConfig,
+		Snapshot:   o.Snapshot,
+	}
+	k, err := kube.NewKubeClient(&cfg)
+	if err != nil {
+		return err
+	}
+	report, err := k.Fit(req, selector)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(o.Output) {
+	case "json":
+		return output.EncodeJSON(os.Stdout, report)
+	case "yaml":
+		return output.EncodeYAML(os.Stdout, report)
+	default:
+		format := output.Format(strings.ToLower(o.Output))
+		f := newCellFormatter(units, format)
+		lang := i18n.Detect(o.Lang)
+		rows := [][]interface{}{lang.Headers("name", "cpuFree", "memoryFree", "podsFree", "fits", "reason")}
+		for _, d := range report.Items {
+			rows = append(rows, []interface{}{d.NodeName, f.cpu(d.CPUFree), f.memory(d.MemoryFree), d.PodsFree, d.Fits, d.Reason})
+		}
+		if err := writeRows(os.Stdout, format, rows); err != nil {
+			return err
+		}
+		if format == output.CSV {
+			return nil
+		}
+		_, err = fmt.Fprintf(os.Stdout, lang.T("replicasFit")+"\n", report.Fits, f.cpu(req.CPU), f.memory(req.Memory), report.Replicas)
+		return err
+	}
+}
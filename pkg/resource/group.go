@@ -0,0 +1,85 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/output"
+)
+
+// writeGroups prints one aggregated row per node group, followed by its
+// member nodes when the groups are expanded. Multi-cluster reports prefix the
+// rows with their cluster.
+func (o *NodeOption) writeGroups(groups []kube.NodeGroup, data []kube.NodeResources, multi bool, format output.Format, f cellFormatter, lang i18n.Lang) error {
+	header := append([]interface{}{o.GroupBy}, lang.Headers("nodes", "status", "cpuUsages", "cpuRequests", "cpuLimits", "cpuCapacity",
+		"memoryUsages", "memoryRequests", "memoryLimits", "memoryCapacity", "pods", "podCapacity", "age")...)
+	if o.QOS {
+		header = append(header, qosHeaders(lang)...)
+	}
+	rows := [][]interface{}{withCluster(multi, lang.T("cluster"), header)}
+	for _, g := range groups {
+		row := nodeSummaryRow(f, g.Value, fmt.Sprint(g.Nodes), g.NodeSummary, o.AddressType, false)
+		if o.QOS {
+			row = append(row, f.qos(g.QOS)...)
+		}
+		rows = append(rows, withCluster(multi, g.Cluster, row))
+		for _, d := range g.Items {
+			row := nodeRow(f, d, "  "+d.NodeName, o.AddressType, false)
+			// the IP column holds the node count in group rows
+			row[1] = ""
+			if o.QOS {
+				row = append(row, f.qos(d.QOS)...)
+			}
+			rows = append(rows, withCluster(multi, d.Cluster, row))
+		}
+	}
+	if !o.Summary {
+		return writeRows(os.Stdout, format, rows)
+	}
+	sum := kube.SummarizeNodes(data)
+	row := nodeSummaryRow(f, lang.T("total"), fmt.Sprint(sum.Nodes), sum, o.AddressType, false)
+	if o.QOS {
+		row = append(row, f.qos(sum.QOS)...)
+	}
+	rows = append(rows, withCluster(multi, "", row))
+	return writeSummary(os.Stdout, format, rows, lang, sum.Warning, sum.Critical)
+}
+
+// writeGroups prints one aggregated row per pod group, see NodeOption.writeGroups
+func (p *PodOption) writeGroups(groups []kube.PodGroup, data []kube.PodsResources, multi bool, format output.Format, f cellFormatter, lang i18n.Lang) error {
+	header := append([]interface{}{p.GroupByLabel}, lang.Headers("namespaces", "pods", "cpuUsages", "cpuRequests", "cpuLimits",
+		"memoryUsages", "memoryRequests", "memoryLimits")...)
+	rows := [][]interface{}{withCluster(multi, lang.T("cluster"), header)}
+	for _, g := range groups {
+		rows = append(rows, withCluster(multi, g.Cluster, podSummaryRow(f, g.Value, g.Namespaces, g.PodSummary)))
+	}
+	if !p.Summary {
+		return writeRows(os.Stdout, format, rows)
+	}
+	sum := kube.SummarizePods(data)
+	namespaces := map[string]bool{}
+	for _, d := range data {
+		namespaces[d.Cluster+"/"+d.Namespace] = true
+	}
+	rows = append(rows, withCluster(multi, "", podSummaryRow(f, lang.T("total"), len(namespaces), sum)))
+	return writeSummary(os.Stdout, format, rows, lang, sum.Warning, sum.Critical)
+}
+
+// withCluster prefixes the row with the cluster in multi-cluster reports
+func withCluster(multi bool, cluster string, row []interface{}) []interface{} {
+	if !multi {
+		return row
+	}
+	return append([]interface{}{cluster}, row...)
+}
+
+// podSummaryRow renders aggregated pods, usage fractions are relative to the limits
+func podSummaryRow(f cellFormatter, name string, namespaces int, sum kube.PodSummary) []interface{} {
+	return []interface{}{name, namespaces, sum.Pods,
+		f.with(f.cpu(sum.CPUUsages), f.exceeds(sum.CPUUsagesFraction)),
+		f.cpu(sum.CPURequests), f.cpu(sum.CPULimits),
+		f.with(f.memory(sum.MemoryUsages), f.exceeds(sum.MemoryUsagesFraction)),
+		f.memory(sum.MemoryRequests), f.memory(sum.MemoryLimits)}
+}
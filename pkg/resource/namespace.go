@@ -0,0 +1,88 @@
+package resource
+
+import (
+	"os"
+	"strings"
+
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/output"
+)
+
+type NamespaceOption struct {
+	Namespace  string
+	Top        int
+	Where      string
+	KubeCtx    string
+	KubeConfig string
+	Snapshot   string
+	Output     string
+	Lang       string
+	Units      string
+	QOS        bool
+}
+
+func (o *NamespaceOption) Validate() error {
+	return i18n.Validate(o.Lang)
+}
+
+func (o *NamespaceOption) RunResourceNamespace() error {
+	printer, err := output.ParsePrinter(o.Output)
+	if err != nil {
+		return err
+	}
+	units, err := kube.ParseUnits(o.Units)
+	if err != nil {
+		return err
+	}
+	where, err := parseWhere[kube.NamespaceResources](o.Where)
+	if err != nil {
+		return err
+	}
+	cfg := kube.ClientConfig{
+		KubeCtx:    o.KubeCtx,
+		KubeConfig: o.KubeConfig,
+		Snapshot:   o.Snapshot,
+	}
+	k, err := kube.NewKubeClient(&cfg)
+	if err != nil {
+		return err
+	}
+	data, err := k.GetNamespaceResources(o.Namespace)
+	if err != nil {
+		return err
+	}
+	data, err = selectRows(data, where, o.Top)
+	if err != nil {
+		return err
+	}
+	if printer != nil {
+		return output.EncodePrinter(os.Stdout, printer, data)
+	}
+	switch strings.ToLower(o.Output) {
+	case "json":
+		return output.EncodeJSON(os.Stdout, data)
+	case "yaml":
+		return output.EncodeYAML(os.Stdout, data)
+	default:
+		format := output.Format(strings.ToLower(o.Output))
+		f := newCellFormatter(units, format)
+		lang := i18n.Detect(o.Lang)
+		header := lang.Headers("namespace", "pods", "cpuUsages", "cpuRequests", "cpuLimits",
+			"memoryUsages", "memoryRequests", "memoryLimits")
+		if o.QOS {
+			header = append(header, qosHeaders(lang)...)
+		}
+		rows := [][]interface{}{header}
+		for _, d := range data {
+			row := []interface{}{d.Namespace, d.Pods,
+				f.cpu(d.CPUUsages), f.cpu(d.CPURequests), f.cpu(d.CPULimits),
+				f.memory(d.MemoryUsages), f.memory(d.MemoryRequests), f.memory(d.MemoryLimits)}
+			if o.QOS {
+				row = append(row, f.qos(d.QOS)...)
+			}
+			rows = append(rows, row)
+		}
+		return writeRows(os.Stdout, format, rows)
+	}
+}
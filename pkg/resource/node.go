@@ -4,29 +4,64 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/gosuri/uitable"
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
 	"github.com/ysicing/kubectl-resource/pkg/kube"
 	"github.com/ysicing/kubectl-resource/pkg/output"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
 type NodeOption struct {
 	Selector   string
 	SortBy     string
+	Reverse    bool
+	Top        int
+	Where      string
 	QPS        float32
 	Burst      int
 	KubeCtx    string
 	KubeConfig string
+	Snapshot   string
 	Output     string
+	Lang       string
+	Units      string
+	Summary    bool
+	GroupBy    string
+	Expand     bool
+	QOS        bool
+	// SchedulableOnly and ExcludeTainted leave out the nodes that can not
+	// receive workloads, so that the totals reflect the usable capacity
+	SchedulableOnly bool
+	ExcludeTainted  []string
+	// AddressType picks the node addresses shown in the IP column
+	AddressType string
+	// Contexts reports on several clusters at once, AllContexts on every
+	// context of the kubeconfig
+	Contexts    []string
+	AllContexts bool
 }
 
-func (o *NodeOption) Validate() {
-	if len(o.SortBy) > 0 {
-		if o.SortBy != "cpu" {
-			o.SortBy = "memory"
+func (o *NodeOption) Validate() error {
+	if err := validateContexts(o.Contexts, o.AllContexts, o.KubeCtx, o.Snapshot); err != nil {
+		return err
+	}
+	if err := i18n.Validate(o.Lang); err != nil {
+		return err
+	}
+	if err := kube.ValidateAddressType(o.AddressType); err != nil {
+		return err
+	}
+	for _, effect := range o.ExcludeTainted {
+		if err := kube.ValidateTaintEffect(effect); err != nil {
+			return err
 		}
 	}
+	if len(o.SortBy) > 0 {
+		return kube.ValidateNodeSortKey(o.SortBy)
+	}
+	return nil
 }
 
 func (o *NodeOption) RunResourceNode() error {
@@ -38,32 +73,262 @@ func (o *NodeOption) RunResourceNode() error {
 			return err
 		}
 	}
-	cfg := kube.ClientConfig{
-		KubeCtx:    o.KubeCtx,
-		KubeConfig: o.KubeConfig,
+	printer, err := output.ParsePrinter(o.Output)
+	if err != nil {
+		return err
+	}
+	units, err := kube.ParseUnits(o.Units)
+	if err != nil {
+		return err
 	}
-	k, err := kube.NewKubeClient(&cfg)
+	where, err := parseWhere[kube.NodeResources](o.Where)
 	if err != nil {
 		return err
 	}
-	data, err := k.GetNodeResources(o.SortBy, selector)
+	contexts, err := resolveContexts(o.Contexts, o.AllContexts, o.KubeConfig)
 	if err != nil {
 		return err
 	}
+	data, now, err := o.fetch(selector, contexts)
+	if err != nil {
+		return err
+	}
+	data = kube.FilterNodes(data, o.SchedulableOnly, o.ExcludeTainted)
+	if len(o.SortBy) > 0 {
+		if err := kube.SortNodeResources(data, o.SortBy, o.Reverse); err != nil {
+			return err
+		}
+	}
+	data, err = selectRows(data, where, o.Top)
+	if err != nil {
+		return err
+	}
+	var items interface{} = data
+	var groups []kube.NodeGroup
+	if len(o.GroupBy) > 0 {
+		groups = kube.GroupNodes(data, o.GroupBy, o.Expand)
+		if len(o.SortBy) > 0 {
+			if err := kube.SortNodeGroups(groups, o.SortBy, o.Reverse); err != nil {
+				return err
+			}
+		}
+		items = groups
+	}
+	if printer != nil {
+		return output.EncodePrinter(os.Stdout, printer, items)
+	}
+	var report interface{} = items
+	if o.Summary {
+		report = nodeReport{Items: items, Summary: kube.SummarizeNodes(data), Clusters: clusterNodeSummaries(data, contexts)}
+	}
 	switch strings.ToLower(o.Output) {
 	case "json":
-		return output.EncodeJSON(os.Stdout, data)
+		return output.EncodeJSON(os.Stdout, report)
 	case "yaml":
-		return output.EncodeYAML(os.Stdout, data)
+		return output.EncodeYAML(os.Stdout, report)
 	default:
-		table := uitable.New()
-		table.AddRow("Name", "IP", "CPU使用", "CPU分配", "CPU限制", "CPU容量", "内存使用", "内存分配", "内存限制", "内存容量", "pod数", "pod容量", "存活时间")
+		format := output.Format(strings.ToLower(o.Output))
+		f := newCellFormatter(units, format)
+		f.now = now
+		lang := i18n.Detect(o.Lang)
+		defer warnNoMetrics(data, lang)
+		if len(o.GroupBy) > 0 {
+			return o.writeGroups(groups, data, len(contexts) > 0, format, f, lang)
+		}
+		wide := format == output.Wide
+		header := lang.Headers("name", ipHeader(o.AddressType), "status", "cpuUsages", "cpuRequests", "cpuLimits", "cpuCapacity",
+			"memoryUsages", "memoryRequests", "memoryLimits", "memoryCapacity", "pods", "podCapacity", "age")
+		if wide {
+			header = append(header, lang.Headers(wideNodeHeaders...)...)
+			for _, t := range otherAddressTypes(o.AddressType) {
+				header = append(header, lang.T(addressHeader(t)))
+			}
+		}
+		if o.QOS {
+			header = append(header, qosHeaders(lang)...)
+		}
+		multi := len(contexts) > 0
+		if multi {
+			header = append([]interface{}{lang.T("cluster")}, header...)
+		}
+		rows := [][]interface{}{header}
 		for _, d := range data {
-			table.AddRow(d.NodeName, d.NodeIP,
-				d.CPUUsages, fmt.Sprintf("%v(%v)", d.CPURequests, d.CPURequestsFraction), fmt.Sprintf("%v(%v)", d.CPULimits, d.CPULimitsFraction), d.CPUCapacity,
-				d.MemoryUsages, fmt.Sprintf("%v(%v)", d.MemoryRequests, d.MemoryRequestsFraction), fmt.Sprintf("%v(%v)", d.MemoryLimits, d.MemoryLimitsFraction), d.MemoryCapacity,
-				fmt.Sprintf("%v(%v)", d.AllocatedPods, d.PodFraction), d.PodCapacity, d.Age)
+			row := nodeRow(f, d, d.NodeName, o.AddressType, wide)
+			if o.QOS {
+				row = append(row, f.qos(d.QOS)...)
+			}
+			if multi {
+				row = append([]interface{}{d.Cluster}, row...)
+			}
+			rows = append(rows, row)
+		}
+		if !o.Summary {
+			return writeRows(os.Stdout, format, rows)
+		}
+		summaryRow := func(sum kube.NodeSummary) []interface{} {
+			row := nodeSummaryRow(f, fmt.Sprintf("%s(%d)", lang.T("total"), sum.Nodes), "", sum, o.AddressType, wide)
+			if o.QOS {
+				row = append(row, f.qos(sum.QOS)...)
+			}
+			return row
+		}
+		for _, c := range clusterNodeSummaries(data, contexts) {
+			rows = append(rows, append([]interface{}{c.Cluster}, summaryRow(c.NodeSummary)...))
+		}
+		sum := kube.SummarizeNodes(data)
+		row := summaryRow(sum)
+		if multi {
+			row = append([]interface{}{""}, row...)
+		}
+		rows = append(rows, row)
+		return writeSummary(os.Stdout, format, rows, lang, sum.Warning, sum.Critical)
+	}
+}
+
+// nodeRow renders a node as table cells, name allows indenting group members
+func nodeRow(f cellFormatter, d kube.NodeResources, name, addressType string, wide bool) []interface{} {
+	row := []interface{}{name, kube.JoinOrNone(d.Addresses(addressType)), d.Status,
+		f.usage(f.cpu(d.CPUUsages), d.NoMetrics),
+		f.with(f.cpu(d.CPURequests), f.exceeds(d.CPURequestsFraction)),
+		f.with(f.cpu(d.CPULimits), f.fraction(d.CPULimitsFraction)),
+		f.cpu(d.CPUCapacity),
+		f.usage(f.memory(d.MemoryUsages), d.NoMetrics),
+		f.with(f.memory(d.MemoryRequests), f.exceeds(d.MemoryRequestsFraction)),
+		f.with(f.memory(d.MemoryLimits), f.fraction(d.MemoryLimitsFraction)),
+		f.memory(d.MemoryCapacity),
+		f.with(d.AllocatedPods, f.exceeds(d.PodFraction)), d.PodCapacity, f.age(d.CreationTimestamp)}
+	if wide {
+		row = append(row, kube.JoinOrNone(d.Roles), d.KubeletVersion, d.OSImage, d.Zone, d.InstanceType,
+			taintSummary(d), kube.JoinOrNone(d.Conditions), d.Schedulable)
+		for _, t := range otherAddressTypes(addressType) {
+			row = append(row, kube.JoinOrNone(d.Addresses(t)))
+		}
+	}
+	return row
+}
+
+// wideNodeHeaders are the message ids of the columns wide output adds, before
+// the other address types
+var wideNodeHeaders = []string{"roles", "kubeletVersion", "osImage", "zone", "instanceType", "taints", "conditions", "schedulable"}
+
+// nodeSummaryRow renders aggregated nodes with the same columns as nodeRow,
+// leaving the wide columns blank
+func nodeSummaryRow(f cellFormatter, name, second string, sum kube.NodeSummary, addressType string, wide bool) []interface{} {
+	row := []interface{}{name, second, "",
+		f.with(f.cpu(sum.CPUUsages), f.fraction(sum.CPUUsagesFraction)),
+		f.with(f.cpu(sum.CPURequests), f.exceeds(sum.CPURequestsFraction)),
+		f.with(f.cpu(sum.CPULimits), f.fraction(sum.CPULimitsFraction)),
+		f.cpu(sum.CPUCapacity),
+		f.with(f.memory(sum.MemoryUsages), f.fraction(sum.MemoryUsagesFraction)),
+		f.with(f.memory(sum.MemoryRequests), f.exceeds(sum.MemoryRequestsFraction)),
+		f.with(f.memory(sum.MemoryLimits), f.fraction(sum.MemoryLimitsFraction)),
+		f.memory(sum.MemoryCapacity),
+		f.with(sum.AllocatedPods, f.exceeds(sum.PodFraction)), sum.PodCapacity, ""}
+	if wide {
+		for i := len(wideNodeHeaders) + len(otherAddressTypes(addressType)); i > 0; i-- {
+			row = append(row, "")
+		}
+	}
+	return row
+}
+
+// otherAddressTypes returns the address types besides the one picked by
+// --address-type for the IP column, wide output adds a column for each
+func otherAddressTypes(addressType string) []string {
+	var others []string
+	for _, t := range kube.AddressTypes() {
+		if t != addressType {
+			others = append(others, t)
+		}
+	}
+	return others
+}
+
+// addressHeader returns the message id of an address type column, the
+// address type starting in lower case
+func addressHeader(addressType string) string {
+	return strings.ToLower(addressType[:1]) + addressType[1:]
+}
+
+// ipHeader returns the message id of the IP column, InternalIP keeps the
+// historical IP header
+func ipHeader(addressType string) string {
+	if addressType == string(corev1.NodeInternalIP) {
+		return "ip"
+	}
+	return addressHeader(addressType)
+}
+
+// warnNoMetrics tells on stderr which nodes lack metrics
+func warnNoMetrics(data []kube.NodeResources, lang i18n.Lang) {
+	var names []string
+	for _, d := range data {
+		if d.NoMetrics {
+			names = append(names, d.NodeName)
 		}
-		return output.EncodeTable(os.Stdout, table)
 	}
+	if len(names) > 0 {
+		fmt.Fprintf(os.Stderr, lang.T("noMetrics")+"\n", len(names), strings.Join(names, ","))
+	}
+}
+
+// taintSummary renders the number of taints followed by their effects, e.g.
+// 2(NoExecute,NoSchedule)
+func taintSummary(d kube.NodeResources) string {
+	if d.Taints == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d(%s)", d.Taints, strings.Join(d.TaintEffects, ","))
+}
+
+// nodeReport is the document printed by --summary in JSON and YAML output
+type nodeReport struct {
+	// Items holds either the nodes or the node groups
+	Items   interface{}      `json:"items" yaml:"items"`
+	Summary kube.NodeSummary `json:"summary" yaml:"summary"`
+	// Clusters holds the summary of every context of multi-cluster reports
+	Clusters []clusterNodeSummary `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+}
+
+type clusterNodeSummary struct {
+	Cluster          string `json:"cluster" yaml:"cluster"`
+	kube.NodeSummary `json:",inline" yaml:",inline"`
+}
+
+// clusterNodeSummaries summarizes the nodes of every context that has any
+func clusterNodeSummaries(data []kube.NodeResources, contexts []string) []clusterNodeSummary {
+	var summaries []clusterNodeSummary
+	for _, name := range contexts {
+		rows := clusterRows(data, func(d *kube.NodeResources) string { return d.Cluster }, name)
+		if len(rows) > 0 {
+			summaries = append(summaries, clusterNodeSummary{Cluster: name, NodeSummary: kube.SummarizeNodes(rows)})
+		}
+	}
+	return summaries
+}
+
+// fetch returns the nodes of the cluster, or of every context concurrently
+// with their cluster set, and the time their ages are measured against
+func (o *NodeOption) fetch(selector labels.Selector, contexts []string) ([]kube.NodeResources, time.Time, error) {
+	if len(contexts) == 0 {
+		cfg := kube.ClientConfig{
+			KubeCtx:    o.KubeCtx,
+			KubeConfig: o.KubeConfig,
+			Snapshot:   o.Snapshot,
+		}
+		k, err := kube.NewKubeClient(&cfg)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		rows, err := k.GetNodeResources(selector)
+		return rows, k.Now(), err
+	}
+	rows, err := fetchContexts(contexts, o.KubeConfig, func(k *kube.KubeClient, cluster string) ([]kube.NodeResources, error) {
+		rows, err := k.GetNodeResources(selector)
+		for i := range rows {
+			rows[i].Cluster = cluster
+		}
+		return rows, err
+	})
+	return rows, time.Now(), err
 }
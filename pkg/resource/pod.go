@@ -2,12 +2,15 @@ package resource
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/gosuri/uitable"
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
 	"github.com/ysicing/kubectl-resource/pkg/kube"
 	"github.com/ysicing/kubectl-resource/pkg/output"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 )
@@ -17,19 +20,49 @@ type PodOption struct {
 	LabelSelector string
 	FieldSelector string
 	SortBy        string
+	Reverse       bool
+	Top           int
+	Where         string
 	QPS           float32
 	Burst         int
 	KubeCtx       string
 	KubeConfig    string
+	Snapshot      string
 	Output        string
+	Lang          string
+	Units         string
+	Summary       bool
+	Health        bool
+	// Phase only keeps the pods of a phase, the active ones by default
+	Phase string
+	// GroupByLabel aggregates the pods by a pod label, falling back to the
+	// label of their namespace
+	GroupByLabel string
+	// Contexts reports on several clusters at once, AllContexts on every
+	// context of the kubeconfig
+	Contexts    []string
+	AllContexts bool
 }
 
-func (p *PodOption) Validate() {
-	if len(p.SortBy) > 0 {
-		if p.SortBy != "cpu" {
-			p.SortBy = "memory"
+func (p *PodOption) Validate() error {
+	if err := validateContexts(p.Contexts, p.AllContexts, p.KubeCtx, p.Snapshot); err != nil {
+		return err
+	}
+	if err := i18n.Validate(p.Lang); err != nil {
+		return err
+	}
+	if len(p.Phase) > 0 {
+		if err := kube.ValidatePodPhase(p.Phase); err != nil {
+			return err
 		}
 	}
+	if len(p.GroupByLabel) > 0 && p.Health {
+		return fmt.Errorf("--group-by-label can not be combined with --health")
+	}
+	if len(p.SortBy) > 0 {
+		return kube.ValidatePodSortKey(p.SortBy)
+	}
+	return nil
 }
 
 func (p *PodOption) RunResourcePod() error {
@@ -48,38 +81,277 @@ func (p *PodOption) RunResourcePod() error {
 			return err
 		}
 	}
-	cfg := kube.ClientConfig{
-		KubeCtx:    p.KubeCtx,
-		KubeConfig: p.KubeConfig,
+	fieldSelector = fields.AndSelectors(fieldSelector, kube.PodPhaseSelector(p.Phase))
+	printer, err := output.ParsePrinter(p.Output)
+	if err != nil {
+		return err
 	}
-	k, err := kube.NewKubeClient(&cfg)
+	units, err := kube.ParseUnits(p.Units)
 	if err != nil {
 		return err
 	}
-	metrics, err := k.GetPodMetricsFromMetricsAPI(p.Namespace, labelSelector, fieldSelector)
+	where, err := parseWhere[kube.PodsResources](p.Where)
 	if err != nil {
 		return err
 	}
-	if len(metrics.Items) == 0 {
+	contexts, err := resolveContexts(p.Contexts, p.AllContexts, p.KubeConfig)
+	if err != nil {
+		return err
+	}
+	data, now, err := p.fetch(labelSelector, fieldSelector, contexts)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
 		return nil
 	}
-	data, err := k.GetPodResources(metrics.Items, p.Namespace, p.SortBy)
+	if len(p.SortBy) > 0 {
+		if err := kube.SortPodsResources(data, p.SortBy, p.Reverse); err != nil {
+			return err
+		}
+	}
+	// --top applies to the groups when grouping, they total every pod
+	top := p.Top
+	if len(p.GroupByLabel) > 0 {
+		top = 0
+	}
+	data, err = selectRows(data, where, top)
 	if err != nil {
 		return err
 	}
+	var items interface{} = data
+	var groups []kube.PodGroup
+	if len(p.GroupByLabel) > 0 {
+		groups = kube.GroupPods(data, p.GroupByLabel)
+		if len(p.SortBy) > 0 {
+			if err := kube.SortPodGroups(groups, p.SortBy, p.Reverse); err != nil {
+				return err
+			}
+		}
+		if groups, err = selectRows(groups, nil, p.Top); err != nil {
+			return err
+		}
+		items = groups
+	}
+	if printer != nil {
+		return output.EncodePrinter(os.Stdout, printer, items)
+	}
+	var report interface{} = items
+	if p.Summary {
+		report = podReport{Items: items, Summary: kube.SummarizePods(data), Clusters: clusterPodSummaries(data, contexts)}
+	}
 	switch strings.ToLower(p.Output) {
 	case "json":
-		return output.EncodeJSON(os.Stdout, data)
+		return output.EncodeJSON(os.Stdout, report)
 	case "yaml":
-		return output.EncodeYAML(os.Stdout, data)
+		return output.EncodeYAML(os.Stdout, report)
 	default:
-		table := uitable.New()
-		table.AddRow("Namespace", "Name", "CPU使用", "CPU分配", "CPU限制", "内存使用", "内存分配", "内存限制")
+		format := output.Format(strings.ToLower(p.Output))
+		wide := format == output.Wide
+		f := newCellFormatter(units, format)
+		f.now = now
+		lang := i18n.Detect(p.Lang)
+		multi := len(contexts) > 0
+		if p.Health {
+			return p.writeHealth(data, contexts, format, f, lang)
+		}
+		if len(p.GroupByLabel) > 0 {
+			return p.writeGroups(groups, data, multi, format, f, lang)
+		}
+		header := lang.Headers("namespace", "name", "cpuUsages", "cpuRequests", "cpuLimits", "memoryUsages", "memoryRequests", "memoryLimits", "age")
+		if wide {
+			header = append(header, lang.Headers("nodeName", "qosClass", "restarts", "phase", "priorityClass", "owner")...)
+		}
+		if multi {
+			header = append([]interface{}{lang.T("cluster")}, header...)
+		}
+		rows := [][]interface{}{header}
 		for _, d := range data {
-			table.AddRow(d.Namespace, d.Name,
-				fmt.Sprintf("%v(%v)", d.CPUUsages, d.CPUUsagesFraction), d.CPURequests, d.CPULimits,
-				fmt.Sprintf("%v(%v)", d.MemoryUsages, d.MemoryUsagesFraction), d.MemoryRequests, d.MemoryLimits)
+			row := []interface{}{d.Namespace, d.Name,
+				f.usage(f.with(f.cpu(d.CPUUsages), f.exceeds(d.CPUUsagesFraction)), d.NoMetrics),
+				f.cpu(d.CPURequests), f.cpu(d.CPULimits),
+				f.usage(f.with(f.memory(d.MemoryUsages), f.exceeds(d.MemoryUsagesFraction)), d.NoMetrics),
+				f.memory(d.MemoryRequests), f.memory(d.MemoryLimits), f.age(d.CreationTimestamp)}
+			if wide {
+				row = append(row, d.NodeName, d.QOSClass, d.Restarts, d.Phase, d.PriorityClass, d.Owner)
+			}
+			if multi {
+				row = append([]interface{}{d.Cluster}, row...)
+			}
+			rows = append(rows, row)
+		}
+		if format != output.CSV {
+			defer writePending(data, multi, format, f, lang)
+		}
+		if !p.Summary {
+			return writeRows(os.Stdout, format, rows)
+		}
+		summaryRow := func(sum kube.PodSummary) []interface{} {
+			row := []interface{}{"", fmt.Sprintf("%s(%d)", lang.T("total"), sum.Pods),
+				f.with(f.cpu(sum.CPUUsages), f.exceeds(sum.CPUUsagesFraction)),
+				f.cpu(sum.CPURequests), f.cpu(sum.CPULimits),
+				f.with(f.memory(sum.MemoryUsages), f.exceeds(sum.MemoryUsagesFraction)),
+				f.memory(sum.MemoryRequests), f.memory(sum.MemoryLimits), ""}
+			if wide {
+				row = append(row, "", "", "", "", "", "")
+			}
+			return row
+		}
+		for _, c := range clusterPodSummaries(data, contexts) {
+			rows = append(rows, append([]interface{}{c.Cluster}, summaryRow(c.PodSummary)...))
+		}
+		sum := kube.SummarizePods(data)
+		row := summaryRow(sum)
+		if multi {
+			row = append([]interface{}{""}, row...)
+		}
+		rows = append(rows, row)
+		return writeSummary(os.Stdout, format, rows, lang, sum.Warning, sum.Critical)
+	}
+}
+
+// podReport is the document printed by --summary in JSON and YAML output
+type podReport struct {
+	// Items holds either the pods or the pod groups
+	Items   interface{}     `json:"items" yaml:"items"`
+	Summary kube.PodSummary `json:"summary" yaml:"summary"`
+	// Clusters holds the summary of every context of multi-cluster reports
+	Clusters []clusterPodSummary `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+}
+
+type clusterPodSummary struct {
+	Cluster         string `json:"cluster" yaml:"cluster"`
+	kube.PodSummary `json:",inline" yaml:",inline"`
+}
+
+// clusterPodSummaries summarizes the pods of every context that has any
+func clusterPodSummaries(data []kube.PodsResources, contexts []string) []clusterPodSummary {
+	var summaries []clusterPodSummary
+	for _, name := range contexts {
+		rows := clusterRows(data, func(d *kube.PodsResources) string { return d.Cluster }, name)
+		if len(rows) > 0 {
+			summaries = append(summaries, clusterPodSummary{Cluster: name, PodSummary: kube.SummarizePods(rows)})
+		}
+	}
+	return summaries
+}
+
+// fetch returns the pods of the cluster, or of every context concurrently
+// with their cluster set, and the time their ages are measured against
+func (p *PodOption) fetch(labelSelector labels.Selector, fieldSelector fields.Selector, contexts []string) ([]kube.PodsResources, time.Time, error) {
+	if len(contexts) == 0 {
+		cfg := kube.ClientConfig{
+			KubeCtx:    p.KubeCtx,
+			KubeConfig: p.KubeConfig,
+			Snapshot:   p.Snapshot,
+		}
+		k, err := kube.NewKubeClient(&cfg)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		rows, err := p.fetchCluster(k, labelSelector, fieldSelector)
+		return rows, k.Now(), err
+	}
+	rows, err := fetchContexts(contexts, p.KubeConfig, func(k *kube.KubeClient, cluster string) ([]kube.PodsResources, error) {
+		rows, err := p.fetchCluster(k, labelSelector, fieldSelector)
+		for i := range rows {
+			rows[i].Cluster = cluster
+		}
+		return rows, err
+	})
+	return rows, time.Now(), err
+}
+
+func (p *PodOption) fetchCluster(k *kube.KubeClient, labelSelector labels.Selector, fieldSelector fields.Selector) ([]kube.PodsResources, error) {
+	data, err := k.GetPodResources(p.Namespace, labelSelector, fieldSelector)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	if p.Health {
+		if err := k.AddPodWarnings(data, p.Namespace); err != nil {
+			return nil, err
 		}
-		return output.EncodeTable(os.Stdout, table)
+	}
+	if len(p.GroupByLabel) > 0 {
+		if err := k.AddNamespaceLabels(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// lastWarningWidth caps the last warning message in tables
+const lastWarningWidth = 60
+
+// writeHealth prints the restarts, OOM kills and Warning events of the pods
+// next to their usage against the limits. CPU usage close to the limits hints
+// at CFS throttling, the throttled periods themselves are not exposed by the
+// metrics API.
+func (p *PodOption) writeHealth(data []kube.PodsResources, contexts []string, format output.Format, f cellFormatter, lang i18n.Lang) error {
+	multi := len(contexts) > 0
+	header := lang.Headers("namespace", "name", "cpuUsages", "cpuLimits", "memoryUsages", "memoryLimits",
+		"restarts", "oomKilled", "lastTerminated", "warnings", "lastWarning")
+	if multi {
+		header = append([]interface{}{lang.T("cluster")}, header...)
+	}
+	rows := [][]interface{}{header}
+	var restarts, oomKilled, warnings int32
+	for _, d := range data {
+		lastWarning := d.LastWarning
+		if r := []rune(lastWarning); format != output.CSV && len(r) > lastWarningWidth {
+			lastWarning = string(r[:lastWarningWidth-3]) + "..."
+		}
+		row := []interface{}{d.Namespace, d.Name,
+			f.usage(f.with(f.cpu(d.CPUUsages), f.exceeds(d.CPUUsagesFraction)), d.NoMetrics), f.cpu(d.CPULimits),
+			f.usage(f.with(f.memory(d.MemoryUsages), f.exceeds(d.MemoryUsagesFraction)), d.NoMetrics), f.memory(d.MemoryLimits),
+			d.Restarts, d.OOMKilled, kube.JoinOrNone(d.LastTerminated), d.Warnings, lastWarning}
+		if multi {
+			row = append([]interface{}{d.Cluster}, row...)
+		}
+		rows = append(rows, row)
+		restarts += d.Restarts
+		oomKilled += d.OOMKilled
+		warnings += d.Warnings
+	}
+	if !p.Summary {
+		return writeRows(os.Stdout, format, rows)
+	}
+	sum := kube.SummarizePods(data)
+	row := []interface{}{"", fmt.Sprintf("%s(%d)", lang.T("total"), sum.Pods),
+		f.with(f.cpu(sum.CPUUsages), f.exceeds(sum.CPUUsagesFraction)), f.cpu(sum.CPULimits),
+		f.with(f.memory(sum.MemoryUsages), f.exceeds(sum.MemoryUsagesFraction)), f.memory(sum.MemoryLimits),
+		restarts, oomKilled, "", warnings, ""}
+	if multi {
+		row = append([]interface{}{""}, row...)
+	}
+	rows = append(rows, row)
+	return writeSummary(os.Stdout, format, rows, lang, sum.Warning, sum.Critical)
+}
+
+// writePending prints the Pending pods below the table with the reason they
+// do not run, e.g. the unschedulable message of the scheduler
+func writePending(data []kube.PodsResources, multi bool, format output.Format, f cellFormatter, lang i18n.Lang) {
+	header := lang.Headers("namespace", "name", "nodeName", "reason", "message", "age")
+	if multi {
+		header = append([]interface{}{lang.T("cluster")}, header...)
+	}
+	rows := [][]interface{}{header}
+	for _, d := range data {
+		if d.Phase != string(corev1.PodPending) {
+			continue
+		}
+		row := []interface{}{d.Namespace, d.Name, kube.OrNone(d.NodeName),
+			kube.OrNone(d.PendingReason), kube.OrNone(d.PendingMessage), f.age(d.CreationTimestamp)}
+		if multi {
+			row = append([]interface{}{d.Cluster}, row...)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 1 {
+		return
+	}
+	fmt.Fprintf(os.Stdout, "\n"+lang.T("pendingPods")+"\n", len(rows)-1)
+	if err := writeRows(os.Stdout, format, rows); err != nil {
+		log.Printf("Couldn't print the pending pods: %s\n", err)
 	}
 }
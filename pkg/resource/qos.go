@@ -0,0 +1,23 @@
+package resource
+
+import (
+	"fmt"
+
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+)
+
+// qosHeaders are the columns appended by --qos
+func qosHeaders(lang i18n.Lang) []interface{} {
+	return lang.Headers("guaranteed", "burstable", "bestEffort", "guaranteedFraction")
+}
+
+// qos renders the pods of every QoS class with their CPU and memory requests,
+// e.g. 12(1500m/3Gi), followed by the share of Guaranteed pods
+func (f cellFormatter) qos(b kube.QOSBreakdown) []interface{} {
+	cells := make([]interface{}, 0, 4)
+	for _, r := range []kube.QOSResources{b.Guaranteed, b.Burstable, b.BestEffort} {
+		cells = append(cells, fmt.Sprintf("%d(%s/%s)", r.Pods, f.cpu(r.CPURequests), f.memory(r.MemoryRequests)))
+	}
+	return append(cells, f.fraction(b.GuaranteedFraction))
+}
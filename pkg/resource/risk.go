@@ -0,0 +1,90 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/output"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type RiskOption struct {
+	Selector   string
+	Top        int
+	Pods       int
+	KubeCtx    string
+	KubeConfig string
+	Snapshot   string
+	Output     string
+	Lang       string
+	Units      string
+}
+
+func (o *RiskOption) Validate() error {
+	return i18n.Validate(o.Lang)
+}
+
+func (o *RiskOption) RunRisk() error {
+	selector := labels.Everything()
+	var err error
+	if len(o.Selector) > 0 {
+		selector, err = labels.Parse(o.Selector)
+		if err != nil {
+			return err
+		}
+	}
+	units, err := kube.ParseUnits(o.Units)
+	if err != nil {
+		return err
+	}
+	cfg := kube.ClientConfig{
+		KubeCtx:    o.KubeCtx,
+		KubeConfig: o.KubeConfig,
+		Snapshot:   o.Snapshot,
+	}
+	k, err := kube.NewKubeClient(&cfg)
+	if err != nil {
+		return err
+	}
+	data, err := k.GetNodeRisks(selector)
+	if err != nil {
+		return err
+	}
+	data, err = selectRows(data, nil, o.Top)
+	if err != nil {
+		return err
+	}
+	if o.Pods > 0 {
+		for i := range data {
+			if len(data[i].Pods) > o.Pods {
+				data[i].Pods = data[i].Pods[:o.Pods]
+			}
+		}
+	}
+	switch strings.ToLower(o.Output) {
+	case "json":
+		return output.EncodeJSON(os.Stdout, data)
+	case "yaml":
+		return output.EncodeYAML(os.Stdout, data)
+	default:
+		format := output.Format(strings.ToLower(o.Output))
+		f := newCellFormatter(units, format)
+		lang := i18n.Detect(o.Lang)
+		rows := [][]interface{}{lang.Headers("name", "qosClass", "memoryUsages", "memoryRequests", "memoryLimits", "memoryCapacity", "aboveRequests", "score")}
+		for _, d := range data {
+			rows = append(rows, []interface{}{d.NodeName, "",
+				f.with(f.memory(d.MemoryUsages), f.exceeds(d.MemoryUsagesFraction)), "",
+				f.with(f.memory(d.MemoryLimits), f.fraction(d.MemoryLimitsFraction)),
+				f.memory(d.MemoryCapacity), "", f.exceeds(d.Score)})
+			for _, p := range d.Pods {
+				rows = append(rows, []interface{}{fmt.Sprintf("  %s/%s", p.Namespace, p.Name), p.QOSClass,
+					f.memory(p.MemoryUsages), f.memory(p.MemoryRequests), f.memory(p.MemoryLimits), "",
+					f.memory(p.MemoryAboveRequests), ""})
+			}
+		}
+		return writeRows(os.Stdout, format, rows)
+	}
+}
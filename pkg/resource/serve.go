@@ -0,0 +1,135 @@
+package resource
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ysicing/kubectl-resource/pkg/exporter"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/output"
+	"github.com/ysicing/kubectl-resource/pkg/ui"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type ServeOption struct {
+	Listen     string
+	Interval   time.Duration
+	KubeCtx    string
+	KubeConfig string
+	Snapshot   string
+	UI         bool
+}
+
+// server computes the reports from a cached client on every request
+type server struct {
+	k *kube.KubeClient
+}
+
+// client returns a client backed by informers, or by the snapshot
+func (o *ServeOption) client(ctx context.Context) (*kube.KubeClient, error) {
+	cfg := kube.ClientConfig{
+		KubeCtx:    o.KubeCtx,
+		KubeConfig: o.KubeConfig,
+		Snapshot:   o.Snapshot,
+	}
+	if len(o.Snapshot) > 0 {
+		return kube.NewKubeClient(&cfg)
+	}
+	return kube.NewCachedKubeClient(ctx, &cfg, kube.CacheOptions{MetricsInterval: o.Interval})
+}
+
+func (o *ServeOption) RunServe() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	k, err := o.client(ctx)
+	if err != nil {
+		return err
+	}
+	s := &server{k: k}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.metrics)
+	if o.UI {
+		mux.HandleFunc("/api/v1/nodes", s.apiNodes)
+		mux.HandleFunc("/api/v1/pods", s.apiPods)
+		mux.HandleFunc("/api/v1/namespaces", s.apiNamespaces)
+		mux.Handle("/", ui.Handler())
+	}
+
+	srv := &http.Server{Addr: o.Listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Couldn't shut down the server: %s\n", err)
+		}
+	}()
+	log.Printf("Serving on %s\n", o.Listen)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// pods computes the pod rows of a namespace, all namespaces when empty
+func (s *server) pods(namespace string) ([]kube.PodsResources, error) {
+	return s.k.GetPodResources(namespace, labels.Everything(), kube.PodPhaseSelector(""))
+}
+
+// metrics serves the node and pod gauges
+func (s *server) metrics(w http.ResponseWriter, r *http.Request) {
+	nodes, err := s.k.GetNodeResources(labels.Everything())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pods, err := s.pods("")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := exporter.Write(w, nodes, pods); err != nil {
+		log.Printf("Couldn't write metrics: %s\n", err)
+	}
+}
+
+// writeJSON answers with the rows, or with the error
+func writeJSON(w http.ResponseWriter, rows interface{}, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := output.EncodeJSON(w, rows); err != nil {
+		log.Printf("Couldn't write response: %s\n", err)
+	}
+}
+
+// apiNodes serves the node rows, ?selector= filters on node labels
+func (s *server) apiNodes(w http.ResponseWriter, r *http.Request) {
+	selector, err := labels.Parse(r.URL.Query().Get("selector"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rows, err := s.k.GetNodeResources(selector)
+	writeJSON(w, rows, err)
+}
+
+// apiPods serves the pod rows, ?namespace= restricts them to a namespace
+func (s *server) apiPods(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.pods(r.URL.Query().Get("namespace"))
+	writeJSON(w, rows, err)
+}
+
+// apiNamespaces serves the namespace rows
+func (s *server) apiNamespaces(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.k.GetNamespaceResources("")
+	writeJSON(w, rows, err)
+}
@@ -0,0 +1,59 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+)
+
+type SnapshotOption struct {
+	Filename   string
+	KubeCtx    string
+	KubeConfig string
+	// Snapshot is the global --from-snapshot flag, save always captures the
+	// cluster so it is rejected
+	Snapshot string
+}
+
+func (o *SnapshotOption) Validate() error {
+	if len(o.Filename) == 0 {
+		return fmt.Errorf("-f is required")
+	}
+	if len(o.Snapshot) > 0 {
+		return fmt.Errorf("--from-snapshot can not be combined with snapshot save, which captures the cluster")
+	}
+	return nil
+}
+
+// RunSave writes the snapshot to the file, "-" writes to stdout
+func (o *SnapshotOption) RunSave() error {
+	cfg := kube.ClientConfig{
+		KubeCtx:    o.KubeCtx,
+		KubeConfig: o.KubeConfig,
+	}
+	k, err := kube.NewKubeClient(&cfg)
+	if err != nil {
+		return err
+	}
+	snapshot, err := k.SaveSnapshot()
+	if err != nil {
+		return err
+	}
+	if o.Filename == "-" {
+		return snapshot.Write(os.Stdout)
+	}
+	f, err := os.Create(o.Filename)
+	if err != nil {
+		return err
+	}
+	if err := snapshot.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stdout, "saved %d nodes, %d pods and their metrics to %s\n", len(snapshot.Nodes), len(snapshot.Pods), o.Filename)
+	return err
+}
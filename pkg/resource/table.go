@@ -0,0 +1,140 @@
+package resource
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/ysicing/kubectl-resource/pkg/i18n"
+	"github.com/ysicing/kubectl-resource/pkg/kube"
+	"github.com/ysicing/kubectl-resource/pkg/output"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// cellFormatter renders typed values as table or CSV cells
+type cellFormatter struct {
+	units kube.Units
+	// color highlights fractions over the warning and critical thresholds,
+	// it is disabled for CSV
+	color bool
+	// now is the time ages are measured against, see kube.KubeClient.Now
+	now time.Time
+}
+
+func newCellFormatter(units kube.Units, format output.Format) cellFormatter {
+	return cellFormatter{units: units, color: format != output.CSV, now: time.Now()}
+}
+
+// cpu renders millicores
+func (f cellFormatter) cpu(v int64) string {
+	return kube.NewCPUResource(v).Format(f.units)
+}
+
+// memory renders bytes
+func (f cellFormatter) memory(v int64) string {
+	return kube.NewMemoryResource(v).Format(f.units)
+}
+
+// fraction renders a percentage without highlighting
+func (f cellFormatter) fraction(v float64) string {
+	return kube.FractionString(v)
+}
+
+// exceeds renders a percentage, highlighted once it crosses a threshold
+func (f cellFormatter) exceeds(v float64) string {
+	if f.color {
+		return kube.ExceedsFraction(v)
+	}
+	return kube.FractionString(v)
+}
+
+// age renders the time elapsed since t
+func (f cellFormatter) age(t metav1.Time) string {
+	return kube.Age(t, f.now)
+}
+
+// usage renders a usage cell, <unknown> when the metrics are missing
+func (f cellFormatter) usage(value interface{}, unknown bool) interface{} {
+	if unknown {
+		return kube.Unknown
+	}
+	return value
+}
+
+// fractionCell is a value with its percentage, rendered 250m(25%) in tables
+// and as two columns in CSV
+type fractionCell struct {
+	value    interface{}
+	fraction string
+}
+
+func (c fractionCell) String() string {
+	return fmt.Sprintf("%v(%v)", c.value, c.fraction)
+}
+
+// with appends a percentage to a value, e.g. 250m(25%)
+func (f cellFormatter) with(value interface{}, fraction string) fractionCell {
+	return fractionCell{value: value, fraction: fraction}
+}
+
+// splitFractions moves the percentages of the fraction cells into a column of
+// their own, headed by the header of the value followed by %, so spreadsheets
+// read both as numbers. The first row is the header.
+func splitFractions(rows [][]interface{}) [][]interface{} {
+	split := map[int]bool{}
+	for _, row := range rows[min(len(rows), 1):] {
+		for i, cell := range row {
+			if _, ok := cell.(fractionCell); ok {
+				split[i] = true
+			}
+		}
+	}
+	if len(split) == 0 {
+		return rows
+	}
+	splitRows := make([][]interface{}, 0, len(rows))
+	for n, row := range rows {
+		splitRow := make([]interface{}, 0, len(row)+len(split))
+		for i, cell := range row {
+			switch c := cell.(type) {
+			case fractionCell:
+				splitRow = append(splitRow, c.value, c.fraction)
+			default:
+				splitRow = append(splitRow, cell)
+				if split[i] && n == 0 {
+					splitRow = append(splitRow, fmt.Sprintf("%v %%", cell))
+				} else if split[i] {
+					splitRow = append(splitRow, "")
+				}
+			}
+		}
+		splitRows = append(splitRows, splitRow)
+	}
+	return splitRows
+}
+
+// writeRows writes the header and rows as a table, or as CSV
+func writeRows(out io.Writer, format output.Format, rows [][]interface{}) error {
+	if format == output.CSV {
+		return output.EncodeCSV(out, splitFractions(rows))
+	}
+	table := uitable.New()
+	for _, row := range rows {
+		table.AddRow(row...)
+	}
+	return output.EncodeTable(out, table)
+}
+
+// writeSummary writes the rows ending with a totals row, followed in tables by
+// the number of rows in warning and critical state
+func writeSummary(out io.Writer, format output.Format, rows [][]interface{}, lang i18n.Lang, warning, critical int) error {
+	if err := writeRows(out, format, rows); err != nil {
+		return err
+	}
+	if format == output.CSV {
+		return nil
+	}
+	_, err := fmt.Fprintf(out, "%s: %d, %s: %d\n", lang.T("warning"), warning, lang.T("critical"), critical)
+	return err
+}
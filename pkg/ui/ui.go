@@ -0,0 +1,22 @@
+// Package ui embeds the read-only dashboard served by kr serve --ui.
+package ui
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed index.html
+var index []byte
+
+// Handler serves the dashboard page, which reads the /api/v1 endpoints
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(index)
+	})
+}